@@ -2,62 +2,38 @@ package runtime
 
 import (
 	"context"
+	"os"
 	"time"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 
 	"github.com/XSAM/go-hybrid/cmdutil"
-	"github.com/XSAM/go-hybrid/environment"
 	"github.com/XSAM/go-hybrid/errorw"
 	"github.com/XSAM/go-hybrid/log"
 	"github.com/XSAM/go-hybrid/log/zapfield"
-	"github.com/XSAM/go-hybrid/metadata"
 )
 
 var flag Flag
 
-func Start() {
-	cmd := rootCmd()
-	cmd.AddCommand(cmdutil.VersionCmd())
-	cmd.AddCommand(flagCmd())
-	cmd.AddCommand(logCmd())
-
-	cmd.Execute()
-}
-
-func rootCmd() *cobra.Command {
-	cmd := cobra.Command{
-		Use:  metadata.AppName(),
-		Long: "Example for go-hybrid.",
-		Run: func(cmd *cobra.Command, args []string) {
-			cmd.Help()
-		},
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
-			if flag.Environment.DevelopmentMode {
-				environment.DevelopmentMode()
-			} else {
-				environment.ProductionMode()
-			}
-
-			if flag.Environment.JSONLogStyle {
-				environment.JSONLogStyle()
-			} else {
-				environment.TextLogStyle()
-			}
-		},
-	}
-
+func Start(name string) {
 	// Default value
 	flag = Flag{
 		Number: 42,
 	}
-	err := cmdutil.ResolveFlagVariable(&cmd, &flag)
+	app, err := cmdutil.NewApp(name, &flag)
 	if err != nil {
-		log.BgLogger().Fatal("resolve flag", zapfield.Error(err))
+		log.BgLogger().Fatal("create app", zapfield.Error(err))
 	}
+	app.Long = "Example for go-hybrid."
+	app.AddCommand(cmdutil.JSONSchemaCmd(&flag))
+	app.AddCommand(flagCmd())
+	app.AddCommand(logCmd())
 
-	return &cmd
+	err = app.Execute()
+	if err != nil {
+		os.Exit(1)
+	}
 }
 
 func flagCmd() *cobra.Command {
@@ -65,7 +41,7 @@ func flagCmd() *cobra.Command {
 		Use:   "flag",
 		Short: "Print flag parse result",
 		Run: func(cmd *cobra.Command, args []string) {
-			log.BgLogger().Info("parse result", zap.Any("flag", flag))
+			log.BgLogger().Info("parse result", zap.Any("flag", cmdutil.Redact(&flag)))
 		},
 	}
 	return &cmd
@@ -1,14 +1,13 @@
 package runtime
 
-import "time"
+import (
+	"time"
 
-type Flag struct {
-	Number      int           `flag:"env"`
-	Duration    time.Duration `flag:""`
-	Environment Environment   `flag:""`
-}
+	"github.com/XSAM/go-hybrid/cmdutil"
+)
 
-type Environment struct {
-	DevelopmentMode bool `flag:"env short=d" flag-usage:"change environment mode to development"`
-	JSONLogStyle    bool `flag:"env name=json-log-style short=j" flag-usage:"change log style to JSON"`
+type Flag struct {
+	Number   int            `flag:"env env-alias=NUMBER"`
+	Duration time.Duration  `flag:""`
+	Password cmdutil.Secret `flag:"env secret"`
 }
@@ -0,0 +1,177 @@
+package cmdutil
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/XSAM/go-hybrid/environment"
+	"github.com/XSAM/go-hybrid/errorw"
+	"github.com/XSAM/go-hybrid/log"
+	"github.com/XSAM/go-hybrid/metadata"
+)
+
+// AppFlag is the built-in flags of applications created by NewApp.
+type AppFlag struct {
+	Mode     string `flag:"env enum=production|staging|development" flag-usage:"environment mode"`
+	LogStyle string `flag:"env enum=text|json" flag-usage:"log style"`
+	// Use the default level of mode if it is nil
+	LogLevel       *zapcore.Level    `flag:"env" flag-usage:"log level, e.g. debug, info, warn"`
+	LogScopeLevels map[string]string `flag:"env" flag-usage:"log levels of scopes, e.g. db=debug"`
+}
+
+// App is the root command of an application created by NewApp.
+type App struct {
+	*cobra.Command
+	// Values of built-in flags
+	Flag AppFlag
+
+	run         func(ctx context.Context, args []string) error
+	flagOptions []Option
+}
+
+// AppOption configure the application created by NewApp.
+type AppOption func(*App)
+
+// WithRun set the function which is run by the root command.
+// ctx carries the background logger, and is canceled when the application receives SIGINT or SIGTERM.
+func WithRun(fn func(ctx context.Context, args []string) error) AppOption {
+	return func(a *App) {
+		a.run = fn
+	}
+}
+
+// WithFlagOptions set the options to resolve flags of the struct.
+func WithFlagOptions(opts ...Option) AppOption {
+	return func(a *App) {
+		a.flagOptions = opts
+	}
+}
+
+// NewApp create the root command of an application, and set the app name.
+// Fields of the struct which f points to are resolved as persistent flags, f can be nil.
+// Built-in flags set the environment mode, log style and log levels before running commands,
+// and version, completion, config-doc and config commands are added.
+//
+//	app, err := cmdutil.NewApp("example", &flag, cmdutil.WithRun(run))
+//	if err != nil {
+//		log.BgLogger().Fatal("create app", zapfield.Error(err))
+//	}
+//	app.Execute()
+func NewApp(name string, f interface{}, opts ...AppOption) (*App, error) {
+	metadata.SetAppName(name)
+
+	a := App{
+		Command: &cobra.Command{Use: name},
+		Flag: AppFlag{
+			Mode:     string(environment.ModeProduction),
+			LogStyle: string(environment.LogStyleText),
+		},
+	}
+	for _, opt := range opts {
+		opt(&a)
+	}
+	if a.run != nil {
+		// Arguments are passed to run, rather than treated as unknown sub commands
+		a.Args = cobra.ArbitraryArgs
+		a.RunE = func(cmd *cobra.Command, args []string) error {
+			ctx := log.WithLogger(cmd.Context(), log.BgLogger())
+			return a.run(ctx, args)
+		}
+	} else {
+		a.Run = func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		}
+	}
+
+	if f != nil {
+		// f is moved to the new app, e.g. apps created by tests, so the previous app isn't kept by bindings
+		if b := findBinding(f); b != nil && b.cmd != nil && !b.cmd.HasParent() {
+			removeCommandBindings(b.cmd)
+		}
+		err := ResolveFlagVariable(a.Command, f, a.flagOptions...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Set up environment before loading values of f, after built-in flags are validated
+	chainPersistentPreRunE(a.Command, func(*cobra.Command, []string) error {
+		return a.Flag.apply()
+	})
+	err := ResolveFlagVariable(a.Command, &a.Flag, a.flagOptions...)
+	if err != nil {
+		return nil, errorw.Wrap(err, "resolve built-in flags")
+	}
+
+	version, configDoc := VersionCmd(), ConfigDocCmd()
+	for _, cmd := range []*cobra.Command{version, configDoc} {
+		cmd.Annotations = map[string]string{skipHookAnnotation: ""}
+	}
+	a.AddCommand(version, CompletionCmd(), configDoc, ConfigCmd())
+	return &a, nil
+}
+
+// Execute run the command with a context, which is canceled when the application receives SIGINT or SIGTERM.
+func (a *App) Execute() error {
+	ctx, cancel := signalContext(context.Background())
+	defer cancel()
+
+	return a.ExecuteContext(ctx)
+}
+
+// apply set the environment mode, log style and log levels.
+func (f AppFlag) apply() error {
+	switch environment.ModeType(f.Mode) {
+	case environment.ModeDevelopment:
+		environment.DevelopmentMode()
+	case environment.ModeStaging:
+		environment.StagingMode()
+	default:
+		environment.ProductionMode()
+	}
+
+	switch environment.LogStyleType(f.LogStyle) {
+	case environment.LogStyleJSON:
+		environment.JSONLogStyle()
+	default:
+		environment.TextLogStyle()
+	}
+
+	if f.LogLevel != nil {
+		log.GetLevels().Set(*f.LogLevel)
+	}
+	for scope, value := range f.LogScopeLevels {
+		var level zapcore.Level
+		err := level.UnmarshalText([]byte(value))
+		if err != nil {
+			return errorw.Wrap(err, "parse log level").
+				WithField("scope", scope).
+				WithField("level", value)
+		}
+		log.GetLevels().SetWithScope(scope, level)
+	}
+	return nil
+}
+
+// signalContext return a context which is canceled when SIGINT or SIGTERM is received.
+func signalContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(ch)
+
+		select {
+		case sig := <-ch:
+			log.BgLogger().Info("received signal, canceling", zap.String("signal", sig.String()))
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
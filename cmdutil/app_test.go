@@ -0,0 +1,140 @@
+package cmdutil
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/XSAM/go-hybrid/environment"
+	"github.com/XSAM/go-hybrid/log"
+	"github.com/XSAM/go-hybrid/metadata"
+)
+
+func resetEnvironment() {
+	environment.ProductionMode()
+	environment.TextLogStyle()
+	log.GetLevels().Set(zapcore.InfoLevel)
+	metadata.SetAppName("")
+}
+
+func TestNewApp(t *testing.T) {
+	defer resetEnvironment()
+
+	var f struct {
+		Name  string `flag:"env required"`
+		Level string `flag:"enum=debug|info"`
+	}
+	var runCtx context.Context
+	var runArgs []string
+	app, err := NewApp("app", &f, WithFlagOptions(WithEnvPrefix("")), WithRun(func(ctx context.Context, args []string) error {
+		runCtx, runArgs = ctx, args
+		return nil
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "app", metadata.AppName())
+
+	// Built-in commands don't require flags
+	for _, name := range []string{"version", "completion", "config-doc", "config"} {
+		cmd, _, err := app.Find([]string{name})
+		require.NoError(t, err)
+		assert.Equal(t, name, cmd.Name())
+	}
+	for _, args := range [][]string{{"completion", "bash"}, {"version"}, {"config-doc"}} {
+		_, _, err = executeCommandC(app.Command, args...)
+		assert.NoError(t, err, args)
+	}
+
+	_, _, err = executeCommandC(app.Command, "--name=foo", "--mode=development", "--log-style=json",
+		"--log-level=warn", "--log-scope-levels=db=debug", "arg")
+	require.NoError(t, err)
+	assert.Equal(t, "foo", f.Name)
+	assert.Equal(t, []string{"arg"}, runArgs)
+	assert.NotNil(t, runCtx.Value(log.ContextKey))
+	assert.Equal(t, environment.ModeDevelopment, environment.Mode)
+	assert.Equal(t, environment.LogStyleJSON, environment.LogStyle)
+	assert.Equal(t, zapcore.WarnLevel, log.GetLevels().Get().Level())
+	assert.Equal(t, zapcore.DebugLevel, log.GetLevels().GetWithScope("db").Level())
+
+	// Invalid values are printed by config
+	_, output, err := executeCommandC(app.Command, "config", "--level=foo")
+	require.NoError(t, err)
+	assert.Contains(t, output, "--level")
+	assert.Contains(t, output, "foo")
+	_, _, err = executeCommandC(app.Command, "--level=foo")
+	assert.Error(t, err)
+}
+
+func TestNewAppAgain(t *testing.T) {
+	defer resetEnvironment()
+
+	var f struct {
+		Name string `flag:""`
+	}
+	previous, err := NewApp("app", &f)
+	require.NoError(t, err)
+	app, err := NewApp("app", &f)
+	require.NoError(t, err)
+
+	// Bindings of the previous app are removed
+	bindings.RLock()
+	defer bindings.RUnlock()
+	assert.NotContains(t, bindings.commands, previous.Command)
+	assert.Len(t, bindings.commands[app.Command], 2)
+}
+
+func TestNewAppWithInvalidFlags(t *testing.T) {
+	defer resetEnvironment()
+
+	app, err := NewApp("app", nil)
+	require.NoError(t, err)
+	_, _, err = executeCommandC(app.Command, "--mode=foo")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"foo" is not one of production|staging|development`)
+
+	app, err = NewApp("app", nil)
+	require.NoError(t, err)
+	_, _, err = executeCommandC(app.Command, "--log-scope-levels=db=foo")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parse log level")
+
+	// Conflict with built-in flags
+	_, err = NewApp("app", &struct {
+		Mode string `flag:""`
+	}{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "flag is already registered: mode")
+}
+
+func TestSignalContext(t *testing.T) {
+	ctx, cancel := signalContext(context.Background())
+	defer cancel()
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context is not canceled by signal")
+	}
+}
+
+func TestAppExecute(t *testing.T) {
+	defer resetEnvironment()
+
+	var canceled bool
+	app, err := NewApp("app", nil, WithRun(func(ctx context.Context, args []string) error {
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGINT))
+		<-ctx.Done()
+		canceled = true
+		return nil
+	}))
+	require.NoError(t, err)
+	app.SetArgs([]string{})
+	assert.NoError(t, app.Execute())
+	assert.True(t, canceled)
+}
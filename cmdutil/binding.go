@@ -0,0 +1,230 @@
+package cmdutil
+
+import (
+	goflag "flag"
+	"reflect"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// SourceType is where the value of a flag comes from.
+type SourceType string
+
+const (
+	SourceDefault SourceType = "default"
+	SourceFlag    SourceType = "flag"
+	SourceEnv     SourceType = "env"
+	SourceConfig  SourceType = "config"
+)
+
+// binding is the flags of a struct registered to a flag set.
+type binding struct {
+	// Command which flags are registered to
+	cmd *cobra.Command
+	// Pointer of the struct
+	target interface{}
+	// Copy of the struct before values are set by env, config file and flag parameters
+	defaults interface{}
+	options  options
+
+	flags   flags
+	flagSet *pflag.FlagSet
+	// Flag set of the standard library which shares values with flagSet, nil for pflag
+	goFlagSet  *goflag.FlagSet
+	validators []*validator
+	groups     []flagGroup
+	// Slices and maps of structs, outer collections first
+	collections []*collection
+
+	mu sync.RWMutex
+	// Sources of values which are not set by flag parameters. Key is full name
+	sources map[string]SourceType
+	// Number of items set by env for slice flags, which are replaced by flag parameters. Key is full name
+	envItems map[string]int
+}
+
+// Bindings created by ResolveFlagVariable, used to find where the value of a field comes from.
+var bindings struct {
+	sync.RWMutex
+	// The latest binding of each struct, key is the pointer of struct
+	targets map[interface{}]*binding
+	// Bindings of each command, in the order they are created
+	commands map[*cobra.Command][]*binding
+}
+
+func newBinding(flagSet *pflag.FlagSet, flags flags) *binding {
+	return &binding{
+		flags:    flags,
+		flagSet:  flagSet,
+		groups:   resolveGroups(flags),
+		sources:  make(map[string]SourceType),
+		envItems: make(map[string]int),
+	}
+}
+
+// copyStruct return the pointer of a deep copy of the struct which p points to.
+func copyStruct(p interface{}) interface{} {
+	return deepCopy(reflect.ValueOf(p)).Interface()
+}
+
+// deepCopy copy pointers, exported fields of structs, slices and maps recursively.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, key := range v.MapKeys() {
+			c.SetMapIndex(key, deepCopy(v.MapIndex(key)))
+		}
+		return c
+	}
+	return v
+}
+
+// structPointer return f if it is a pointer, otherwise return the pointer of a copy of f,
+// so fields are addressable.
+func structPointer(f interface{}) interface{} {
+	v := reflect.ValueOf(f)
+	if v.Kind() == reflect.Ptr {
+		return f
+	}
+
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	return p.Interface()
+}
+
+// addBinding add b to bindings, the previous binding of the same struct is removed.
+func addBinding(b *binding) {
+	bindings.Lock()
+	defer bindings.Unlock()
+
+	if bindings.targets == nil {
+		bindings.targets = make(map[interface{}]*binding)
+		bindings.commands = make(map[*cobra.Command][]*binding)
+	}
+	if old, ok := bindings.targets[b.target]; ok {
+		removeBinding(old)
+	}
+	bindings.targets[b.target] = b
+	if b.cmd != nil {
+		bindings.commands[b.cmd] = append(bindings.commands[b.cmd], b)
+	}
+}
+
+// removeBinding remove b from bindings, bindings must be locked.
+// Persistent hooks of the command are removed with its last binding.
+func removeBinding(b *binding) {
+	delete(bindings.targets, b.target)
+	if b.cmd == nil {
+		return
+	}
+
+	var list []*binding
+	for _, v := range bindings.commands[b.cmd] {
+		if v != b {
+			list = append(list, v)
+		}
+	}
+	if len(list) > 0 {
+		bindings.commands[b.cmd] = list
+		return
+	}
+	delete(bindings.commands, b.cmd)
+	removePersistentHooks(b.cmd)
+}
+
+// removeCommandBindings remove bindings and persistent hooks of cmd.
+func removeCommandBindings(cmd *cobra.Command) {
+	bindings.Lock()
+	defer bindings.Unlock()
+
+	for _, b := range bindings.commands[cmd] {
+		delete(bindings.targets, b.target)
+	}
+	delete(bindings.commands, cmd)
+	removePersistentHooks(cmd)
+}
+
+// findBinding return the latest binding of struct which f points to, nil if f isn't a pointer.
+func findBinding(f interface{}) *binding {
+	// Only pointers are resolved, and struct values may not be comparable
+	if reflect.ValueOf(f).Kind() != reflect.Ptr {
+		return nil
+	}
+
+	bindings.RLock()
+	defer bindings.RUnlock()
+
+	return bindings.targets[f]
+}
+
+func (b *binding) setSource(fullName string, source SourceType) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sources[fullName] = source
+}
+
+// source return where the value of flag comes from.
+// Flag parameter has the highest priority.
+func (b *binding) source(fullName string) SourceType {
+	if f := b.flagSet.Lookup(fullName); f != nil && f.Changed {
+		return SourceFlag
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if source, ok := b.sources[fullName]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// Source return where the value of a field comes from.
+// p is the pointer of a field in the struct resolved by ResolveFlagVariable.
+// Return empty if p isn't a resolved field.
+//
+//	cmdutil.Source(&flag.Number)
+func Source(p interface{}) SourceType {
+	bindings.RLock()
+	defer bindings.RUnlock()
+
+	for _, b := range bindings.targets {
+		for _, v := range b.flags {
+			if v.Pointer == p {
+				return b.source(v.FullName)
+			}
+		}
+	}
+	return ""
+}
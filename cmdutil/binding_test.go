@@ -0,0 +1,130 @@
+package cmdutil
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveFlagVariableWithPointer(t *testing.T) {
+	// Monkey patch
+	monkey.Patch(os.Getenv, func(key string) string {
+		switch key {
+		case "ENV":
+			return "env"
+		}
+		return ""
+	})
+	defer monkey.Unpatch(os.Getenv)
+
+	defaultValue := 42
+	m := struct {
+		Int      *int           `flag:""`
+		Default  *int           `flag:""`
+		Duration *time.Duration `flag:""`
+		Bool     *bool          `flag:""`
+		Slice    *[]string      `flag:""`
+		Env      *string        `flag:"env"`
+		Unset    *string        `flag:"env"`
+	}{
+		Default: &defaultValue,
+	}
+
+	cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+	err := ResolveFlagVariable(&cmd, &m)
+	require.NoError(t, err)
+
+	assert.Equal(t, "42", cmd.Flag("default").DefValue)
+	assert.Equal(t, "", cmd.Flag("int").DefValue)
+	assert.Equal(t, "duration", cmd.Flag("duration").Value.Type())
+	// Only pointers to bool are bool flags, nil pointers of others have no default value in usage
+	assert.Equal(t, "true", cmd.Flag("bool").NoOptDefVal)
+	usages := cmd.PersistentFlags().FlagUsages()
+	for _, usage := range []string{"--int int", "--duration duration", "--slice strings"} {
+		assert.Regexp(t, "(?m)^ +"+usage+" *$", usages)
+	}
+
+	_, _, err = executeCommandC(&cmd, "--int=0", "--duration=1s", "--bool", "--slice=a,b")
+	require.NoError(t, err)
+
+	require.NotNil(t, m.Int)
+	assert.Equal(t, 0, *m.Int)
+	assert.Equal(t, 42, *m.Default)
+	assert.Equal(t, time.Second, *m.Duration)
+	assert.Equal(t, true, *m.Bool)
+	assert.Equal(t, []string{"a", "b"}, *m.Slice)
+	assert.Equal(t, "env", *m.Env)
+	assert.Nil(t, m.Unset)
+}
+
+func TestSource(t *testing.T) {
+	path, cleanup := writeTempFile(t, "config.yaml", "config: config\nenv: config\n")
+	defer cleanup()
+
+	// Monkey patch
+	monkey.Patch(os.Getenv, func(key string) string {
+		switch key {
+		case "ENV", "FLAG":
+			return "env"
+		}
+		return ""
+	})
+	defer monkey.Unpatch(os.Getenv)
+
+	m := struct {
+		ConfigFile string `flag:"config-file"`
+		Flag       string `flag:"env"`
+		Env        string `flag:"env"`
+		Config     string `flag:""`
+		Default    int    `flag:""`
+		Pointer    *int   `flag:""`
+		Ignored    string
+	}{}
+
+	cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+	err := ResolveFlagVariable(&cmd, &m)
+	require.NoError(t, err)
+
+	_, _, err = executeCommandC(&cmd, "--flag=flag", "--config-file", path)
+	require.NoError(t, err)
+
+	assert.Equal(t, SourceFlag, Source(&m.ConfigFile))
+	assert.Equal(t, SourceFlag, Source(&m.Flag))
+	assert.Equal(t, SourceEnv, Source(&m.Env))
+	assert.Equal(t, SourceConfig, Source(&m.Config))
+	assert.Equal(t, SourceDefault, Source(&m.Default))
+	assert.Equal(t, SourceDefault, Source(&m.Pointer))
+	assert.Nil(t, m.Pointer)
+
+	// Not a resolved field
+	assert.Equal(t, SourceType(""), Source(&m.Ignored))
+	assert.Equal(t, SourceType(""), Source(m.Default))
+}
+
+func TestResolveFlagVariableAgain(t *testing.T) {
+	var f struct {
+		Name string `flag:""`
+	}
+	var cmds []*cobra.Command
+	for i := 0; i < 3; i++ {
+		cmd := &cobra.Command{Use: "app", Run: func(*cobra.Command, []string) {}}
+		require.NoError(t, ResolveFlagVariable(cmd, &f))
+		cmds = append(cmds, cmd)
+	}
+
+	// Previous bindings of the struct are replaced
+	_, _, err := executeCommandC(cmds[2], "--name=foo")
+	require.NoError(t, err)
+	assert.Equal(t, SourceFlag, Source(&f.Name))
+	bindings.RLock()
+	defer bindings.RUnlock()
+	assert.Same(t, cmds[2], bindings.targets[&f].cmd)
+	assert.NotContains(t, bindings.commands, cmds[0])
+	assert.NotContains(t, bindings.commands, cmds[1])
+	assert.Len(t, bindings.commands[cmds[2]], 1)
+}
@@ -0,0 +1,304 @@
+package cmdutil
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/XSAM/go-hybrid/errorw"
+)
+
+// slotMode decide the items of slices and maps of structs which have flags.
+type slotMode int
+
+const (
+	// Items of current value
+	slotCurrent slotMode = iota
+	// Grow items by tags and env, so items can be set by flag parameters, env and config file
+	slotGrow
+	// A zero item for each slice or map, which describes the structure of items
+	slotTemplate
+)
+
+// templateKey is the key of the item of maps in slotTemplate mode.
+const templateKey = "*"
+
+// collection is a slice or map of structs, each item has its own flags.
+// e.g. --upstream-0-url for []Upstream, --backend-foo-url for map[string]Backend
+type collection struct {
+	FullName string
+	// Keys of config file, items are in the next level
+	Path []string
+
+	field reflect.Value
+	// Value of field before growing
+	original reflect.Value
+	// Items of slots. Grown slice for slices
+	backing reflect.Value
+	// Items which exist before resolving. Key is the index or key of item
+	defaults map[string]bool
+	slots    []*slot
+}
+
+// slot is an item of collection.
+type slot struct {
+	collection *collection
+	// Index of slices, key of maps
+	key string
+	// Pointer of item
+	value reflect.Value
+	// Item has value, set by applyCollections
+	used bool
+}
+
+// isCollection return true for slices of structs and maps of structs with string keys.
+func isCollection(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Struct
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.Struct
+	}
+	return false
+}
+
+func (c *collection) isSlice() bool {
+	return c.field.Kind() == reflect.Slice
+}
+
+// newCollection create slots of collection field by the mode of resolver.
+func (r resolver) newCollection(field reflect.Value, tag flagTag, fullName string, path []string) *collection {
+	c := collection{
+		FullName: fullName,
+		Path:     path,
+		field:    field,
+		defaults: make(map[string]bool),
+	}
+	if r.collections != nil {
+		*r.collections = append(*r.collections, &c)
+	}
+
+	if c.isSlice() {
+		n := field.Len()
+		for i := 0; i < n; i++ {
+			c.defaults[strconv.Itoa(i)] = true
+		}
+		switch r.mode {
+		case slotGrow:
+			if tag.items > n {
+				n = tag.items
+			}
+			if items := envItems(r.options.env(fullName)); items > n {
+				n = items
+			}
+		case slotTemplate:
+			n = 1
+		}
+
+		c.original = reflect.ValueOf(field.Interface())
+		c.backing = c.original
+		if r.mode == slotTemplate {
+			c.backing = reflect.MakeSlice(field.Type(), n, n)
+		} else if n > field.Len() {
+			c.backing = reflect.MakeSlice(field.Type(), n, n)
+			reflect.Copy(c.backing, field)
+			if r.mode == slotGrow {
+				// Items are set through the grown slice, which is trimmed by applyCollections
+				field.Set(c.backing)
+			}
+		}
+		for i := 0; i < n; i++ {
+			c.slots = append(c.slots, &slot{collection: &c, key: strconv.Itoa(i), value: c.backing.Index(i).Addr()})
+		}
+		return &c
+	}
+
+	// Map values are not addressable, so items are copied and written back by applyCollections
+	keys := make([]string, 0, field.Len())
+	for _, key := range field.MapKeys() {
+		keys = append(keys, key.String())
+		c.defaults[key.String()] = true
+	}
+	switch r.mode {
+	case slotGrow:
+		for _, key := range tag.keys {
+			if !c.defaults[key] {
+				keys = append(keys, key)
+			}
+		}
+	case slotTemplate:
+		keys = []string{templateKey}
+	}
+	sort.Strings(keys)
+
+	c.backing = reflect.MakeMap(field.Type())
+	for _, key := range keys {
+		item := reflect.New(field.Type().Elem())
+		if value := field.MapIndex(reflect.ValueOf(key).Convert(field.Type().Key())); value.IsValid() && r.mode != slotTemplate {
+			item.Elem().Set(value)
+		}
+		c.slots = append(c.slots, &slot{collection: &c, key: key, value: item})
+	}
+	return &c
+}
+
+// envItems return the number of items found in env.
+// e.g. 2 for UPSTREAM_1_URL with prefix UPSTREAM
+func envItems(prefix string) int {
+	var n int
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, prefix+"_") {
+			continue
+		}
+		rest := kv[len(prefix)+1:]
+		end := strings.IndexAny(rest, "_=")
+		if end <= 0 {
+			continue
+		}
+		if i, err := strconv.Atoi(rest[:end]); err == nil && i >= n {
+			n = i + 1
+		}
+	}
+	return n
+}
+
+func appendString(list []string, s string) []string {
+	result := make([]string, 0, len(list)+1)
+	return append(append(result, list...), s)
+}
+
+func appendSlot(list []*slot, s *slot) []*slot {
+	result := make([]*slot, 0, len(list)+1)
+	return append(append(result, list...), s)
+}
+
+// slotAt return the slot which key is at index i of path, nil if not found.
+func (v flag) slotAt(i int) *slot {
+	for _, s := range v.Slots {
+		if len(s.collection.Path) == i {
+			return s
+		}
+	}
+	return nil
+}
+
+// active return false if the flag belongs to an unused item.
+func (v flag) active() bool {
+	for _, s := range v.Slots {
+		if !s.used {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultItem return true if the flag belongs to items which exist before resolving,
+// so its default value is treated as set.
+func (v flag) defaultItem() bool {
+	if len(v.Slots) == 0 {
+		return false
+	}
+	for _, s := range v.Slots {
+		if !s.collection.defaults[s.key] {
+			return false
+		}
+	}
+	return true
+}
+
+// applyCollections set used items to slices and maps.
+// Items are used if they exist before resolving or any of their flags is set.
+func (b *binding) applyCollections() error {
+	for _, c := range b.collections {
+		for _, s := range c.slots {
+			s.used = c.defaults[s.key]
+		}
+	}
+	for _, v := range b.flags {
+		if b.source(v.FullName) == SourceDefault {
+			continue
+		}
+		for _, s := range v.Slots {
+			s.used = true
+		}
+	}
+
+	// Inner collections first, since items are copied into outer collections
+	for i := len(b.collections) - 1; i >= 0; i-- {
+		c := b.collections[i]
+		if !c.isSlice() {
+			for _, s := range c.slots {
+				if !s.used {
+					continue
+				}
+				if c.field.IsNil() {
+					c.field.Set(reflect.MakeMap(c.field.Type()))
+				}
+				c.field.SetMapIndex(reflect.ValueOf(s.key).Convert(c.field.Type().Key()), s.value.Elem())
+			}
+			continue
+		}
+
+		n := 0
+		for i, s := range c.slots {
+			if s.used {
+				n = i + 1
+			}
+		}
+		for _, s := range c.slots[:n] {
+			if !s.used {
+				return errorw.NewMessagef("sparse indices of %s: %s is not set", c.FullName, s.key).
+					WithField("name", c.FullName).
+					WithField("items", n)
+			}
+		}
+		if n == 0 {
+			// Keep nil or empty slice
+			c.field.Set(c.original)
+		} else {
+			c.field.Set(c.backing.Slice(0, n))
+		}
+	}
+	return nil
+}
+
+// checkConfigItems return error if config has items which don't have slots.
+func (c *collection) checkConfigItems(config map[string]interface{}) error {
+	value, ok := lookupConfigPath(config, c.Path)
+	if !ok {
+		return nil
+	}
+
+	switch items := value.(type) {
+	case []interface{}:
+		if c.isSlice() && len(items) > len(c.slots) {
+			return errorw.NewMessagef("too many items of %s, increase it by items tag", c.FullName).
+				WithField("name", c.FullName).
+				WithField("items", len(items)).
+				WithField("max", len(c.slots))
+		}
+	case map[string]interface{}:
+		if c.isSlice() {
+			return nil
+		}
+		keys := make([]string, 0, len(items))
+		for key := range items {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			found := false
+			for _, s := range c.slots {
+				found = found || s.key == key
+			}
+			if !found {
+				return errorw.NewMessagef("unknown key %s of %s, declare it by keys tag", key, c.FullName).
+					WithField("name", c.FullName)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package cmdutil
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testUpstream struct {
+	URL     string        `flag:"env required"`
+	Timeout time.Duration `flag:""`
+}
+
+type testBackend struct {
+	Host string `flag:""`
+	Port int    `flag:"max=65535"`
+}
+
+type testCollectionFlag struct {
+	ConfigFile string                 `flag:"config-file"`
+	Upstreams  []testUpstream         `flag:"name=upstream items=2"`
+	Backends   map[string]testBackend `flag:"name=backend keys=bar"`
+}
+
+func TestResolveFlagVariableWithCollections(t *testing.T) {
+	require.NoError(t, os.Setenv("UPSTREAM_2_URL", "env"))
+	defer os.Unsetenv("UPSTREAM_2_URL")
+
+	f := testCollectionFlag{
+		Upstreams: []testUpstream{{URL: "default", Timeout: time.Second}},
+		Backends:  map[string]testBackend{"foo": {Host: "foo", Port: 80}},
+	}
+	cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+	require.NoError(t, ResolveFlagVariable(&cmd, &f))
+
+	// Slots of default items, items tag and env
+	for _, name := range []string{
+		"upstream-0-url", "upstream-1-url", "upstream-2-url", "upstream-2-timeout",
+		"backend-foo-host", "backend-bar-port",
+	} {
+		assert.NotNil(t, cmd.Flag(name), name)
+	}
+	assert.Equal(t, "UPSTREAM_1_URL", cmd.Flag("upstream-1-url").Usage[len("[env "):len("[env ")+len("UPSTREAM_1_URL")])
+
+	_, _, err := executeCommandC(&cmd, "--upstream-1-url=flag", "--upstream-1-timeout=2s", "--backend-bar-port=8080")
+	require.NoError(t, err)
+	assert.Equal(t, []testUpstream{
+		{URL: "default", Timeout: time.Second},
+		{URL: "flag", Timeout: 2 * time.Second},
+		{URL: "env"},
+	}, f.Upstreams)
+	assert.Equal(t, map[string]testBackend{
+		"foo": {Host: "foo", Port: 80},
+		"bar": {Port: 8080},
+	}, f.Backends)
+}
+
+func TestResolveFlagVariableWithUnusedItems(t *testing.T) {
+	var f testCollectionFlag
+	cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+	require.NoError(t, ResolveFlagVariable(&cmd, &f))
+
+	// Required fields of unused items are not validated
+	_, _, err := executeCommandC(&cmd)
+	require.NoError(t, err)
+	assert.Nil(t, f.Upstreams)
+	assert.Nil(t, f.Backends)
+
+	// Validate used items
+	_, _, err = executeCommandC(&cmd, "--upstream-0-timeout=1s")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "required flag --upstream-0-url")
+}
+
+func TestResolveFlagVariableWithSparseItems(t *testing.T) {
+	var f testCollectionFlag
+	cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+	require.NoError(t, ResolveFlagVariable(&cmd, &f))
+
+	_, _, err := executeCommandC(&cmd, "--upstream-1-url=foo")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sparse indices of upstream: 0 is not set")
+}
+
+func TestResolveFlagVariableWithCollectionsInConfigFile(t *testing.T) {
+	testCases := []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name: "valid",
+			content: `
+upstream:
+  - url: a
+  - url: b
+    timeout: 1s
+backend:
+  bar:
+    host: bar
+`,
+		},
+		{
+			name: "too many items",
+			content: `
+upstream: [{url: a}, {url: b}, {url: c}]
+`,
+			expectedError: "too many items of upstream, increase it by items tag",
+		},
+		{
+			name: "unknown key",
+			content: `
+backend:
+  foo:
+    host: foo
+`,
+			expectedError: "unknown key foo of backend, declare it by keys tag",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, cleanup := writeTempFile(t, "config.yaml", tc.content)
+			defer cleanup()
+
+			var f testCollectionFlag
+			cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+			require.NoError(t, ResolveFlagVariable(&cmd, &f))
+
+			_, _, err := executeCommandC(&cmd, "--config-file", path, "--upstream-0-url=flag")
+			if tc.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, []testUpstream{{URL: "flag"}, {URL: "b", Timeout: time.Second}}, f.Upstreams)
+			assert.Equal(t, map[string]testBackend{"bar": {Host: "bar"}}, f.Backends)
+		})
+	}
+}
+
+func TestResolveFlagVariableWithEmptyCollectionsInConfigFile(t *testing.T) {
+	testCases := []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name:          "slice without items",
+			content:       "upstream: [{url: a}]\n",
+			expectedError: "too many items of upstream, increase it by items tag",
+		},
+		{
+			name:          "map without keys",
+			content:       "backend: {foo: {host: foo}}\n",
+			expectedError: "unknown key foo of backend, declare it by keys tag",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, cleanup := writeTempFile(t, "config.yaml", tc.content)
+			defer cleanup()
+
+			var f struct {
+				ConfigFile string                 `flag:"config-file"`
+				Upstreams  []testUpstream         `flag:"name=upstream"`
+				Backends   map[string]testBackend `flag:"name=backend"`
+			}
+			cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+			require.NoError(t, ResolveFlagVariable(&cmd, &f))
+
+			_, _, err := executeCommandC(&cmd, "--config-file", path)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectedError)
+		})
+	}
+}
+
+func TestJSONSchemaWithCollections(t *testing.T) {
+	data, err := JSONSchema(&testCollectionFlag{})
+	require.NoError(t, err)
+
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &schema))
+	properties := schema["properties"].(map[string]interface{})
+
+	upstream := properties["upstream"].(map[string]interface{})
+	assert.Equal(t, "array", upstream["type"])
+	items := upstream["items"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"url"}, items["required"])
+	assert.Contains(t, items["properties"], "timeout")
+
+	backend := properties["backend"].(map[string]interface{})
+	assert.Equal(t, "object", backend["type"])
+	assert.Contains(t, backend["additionalProperties"].(map[string]interface{})["properties"], "port")
+}
+
+func TestDumpWithCollections(t *testing.T) {
+	f := testCollectionFlag{
+		Upstreams: []testUpstream{{URL: "a"}},
+		Backends:  map[string]testBackend{"foo": {Port: 80}},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"config-file":        "",
+		"upstream-0-url":     "a",
+		"upstream-0-timeout": time.Duration(0),
+		"backend-foo-host":   "",
+		"backend-foo-port":   80,
+	}, Dump(&f))
+}
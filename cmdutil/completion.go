@@ -0,0 +1,102 @@
+package cmdutil
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/XSAM/go-hybrid/errorw"
+)
+
+// Completions of values
+const (
+	CompleteFile = "file"
+	CompleteDir  = "dir"
+)
+
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// skipHookAnnotation is the annotation of commands which don't load config file and validate values,
+// since they don't use the values of flags.
+const skipHookAnnotation = "cmdutil_skip_hook"
+
+// skipHook return true for completion commands, and commands with skipHookAnnotation.
+func skipHook(cmd *cobra.Command) bool {
+	if cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd {
+		return true
+	}
+	_, ok := cmd.Annotations[skipHookAnnotation]
+	return ok
+}
+
+// configFileExtensions are extensions of supported config file formats.
+var configFileExtensions = []string{"yaml", "yml", "json", "toml"}
+
+// completion return the completion function of flag, nil if the flag has no completion.
+// Values of enum are completed first, then files or directories.
+// Config file flags complete files of supported formats by default.
+func (v flag) completion() (completionFunc, error) {
+	complete, extensions := v.Complete, v.Extensions
+	if len(extensions) > 0 && complete == "" {
+		complete = CompleteFile
+	}
+	if v.ConfigFile && complete == "" {
+		complete, extensions = CompleteFile, configFileExtensions
+	}
+
+	switch {
+	case len(v.Rules.OneOf) > 0:
+		values := v.Rules.OneOf
+		return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return values, cobra.ShellCompDirectiveNoFileComp
+		}, nil
+	case complete == CompleteFile && len(extensions) > 0:
+		return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return extensions, cobra.ShellCompDirectiveFilterFileExt
+		}, nil
+	case complete == CompleteFile:
+		return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveDefault
+		}, nil
+	case complete == CompleteDir:
+		return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveFilterDirs
+		}, nil
+	case complete != "":
+		return nil, errorw.NewMessagef("not supported completion: %s", complete).WithField("name", v.FullName)
+	}
+	return nil, nil
+}
+
+// CompletionCmd return a command which print the shell completion script of the root command.
+// Flags with complete, ext or enum tags complete their values.
+//
+//	source <(app completion bash)
+func CompletionCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "completion [bash|zsh|fish|powershell]",
+		Short: "Print shell completion script",
+		Long: `Print shell completion script.
+
+  bash:       source <(app completion bash)
+  zsh:        app completion zsh > "${fpath[1]}/_app"
+  fish:       app completion fish | source
+  powershell: app completion powershell | Out-String | Invoke-Expression`,
+		Annotations:           map[string]string{skipHookAnnotation: ""},
+		DisableFlagsInUseLine: true,
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		Args:                  cobra.ExactValidArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, out := cmd.Root(), cmd.OutOrStdout()
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletionV2(out, true)
+			case "zsh":
+				return root.GenZshCompletion(out)
+			case "fish":
+				return root.GenFishCompletion(out, true)
+			default:
+				return root.GenPowerShellCompletionWithDesc(out)
+			}
+		},
+	}
+	return &cmd
+}
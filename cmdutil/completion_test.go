@@ -0,0 +1,73 @@
+package cmdutil
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveFlagVariableWithCompletion(t *testing.T) {
+	root := &cobra.Command{Use: "app"}
+	sub := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(sub)
+	require.NoError(t, ResolveFlagVariable(root, &struct {
+		ConfigFile string `flag:"config-file"`
+		Format     string `flag:"enum=text|json"`
+		Cert       string `flag:"ext=pem|crt"`
+		Log        string `flag:"complete=file"`
+		Dir        string `flag:"complete=dir"`
+		Name       string `flag:""`
+	}{}))
+
+	testCases := []struct {
+		flag     string
+		expected string
+	}{
+		{flag: "--config-file", expected: "yaml\nyml\njson\ntoml\n:8\n"},
+		{flag: "--format", expected: "text\njson\n:4\n"},
+		{flag: "--cert", expected: "pem\ncrt\n:8\n"},
+		{flag: "--log", expected: ":0\n"},
+		{flag: "--dir", expected: ":16\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.flag, func(t *testing.T) {
+			_, output, err := executeCommandC(root, cobra.ShellCompNoDescRequestCmd, "sub", tc.flag, "")
+			require.NoError(t, err)
+			assert.Contains(t, output, tc.expected)
+		})
+	}
+
+	// Enum flags are optional unless they are required
+	_, _, err := executeCommandC(root, "sub")
+	assert.NoError(t, err)
+	_, _, err = executeCommandC(root, "sub", "--format=xml")
+	assert.Error(t, err)
+}
+
+func TestResolveFlagVariableWithWrongCompletion(t *testing.T) {
+	err := ResolveFlagVariable(&cobra.Command{}, &struct {
+		Name string `flag:"complete=foo"`
+	}{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported completion: foo")
+}
+
+func TestCompletionCmd(t *testing.T) {
+	root := &cobra.Command{Use: "app"}
+	root.AddCommand(CompletionCmd())
+	// Values are not validated
+	require.NoError(t, ResolveFlagVariable(root, &struct {
+		Name string `flag:"required"`
+	}{}))
+
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		_, output, err := executeCommandC(root, "completion", shell)
+		require.NoError(t, err)
+		assert.Contains(t, output, "app", shell)
+	}
+
+	_, _, err := executeCommandC(root, "completion", "foo")
+	assert.Error(t, err)
+}
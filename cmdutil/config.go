@@ -0,0 +1,164 @@
+package cmdutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+
+	"github.com/XSAM/go-hybrid/errorw"
+)
+
+// readConfigFile decode config file by its extension.
+// Supported formats: YAML, JSON and TOML.
+func readConfigFile(path string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errorw.Wrap(err, "read config file").WithField("path", path)
+	}
+
+	config := make(map[string]interface{})
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &config)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		// Prevent large integers from being formatted as float
+		decoder.UseNumber()
+		err = decoder.Decode(&config)
+	case ".toml":
+		err = toml.Unmarshal(data, &config)
+	default:
+		return nil, errorw.NewMessagef("not supported config file format: %s", ext).WithField("path", path)
+	}
+	if err != nil {
+		return nil, errorw.Wrap(err, "decode config file").WithField("path", path)
+	}
+	return config, nil
+}
+
+// lookupConfigValue find the value of flag in config.
+// Nested sections have priority over the full name key at the top level.
+func lookupConfigValue(config map[string]interface{}, f flag) (interface{}, bool) {
+	if value, ok := lookupConfigPath(config, f.Path); ok {
+		return value, true
+	}
+
+	value, ok := config[f.FullName]
+	return value, ok && value != nil
+}
+
+// lookupConfigPath find the value by keys of nested sections.
+// Items of lists are found by index.
+func lookupConfigPath(config map[string]interface{}, path []string) (interface{}, bool) {
+	if len(path) == 0 {
+		return nil, false
+	}
+
+	var value interface{} = config
+	for _, key := range path {
+		switch section := value.(type) {
+		case map[string]interface{}:
+			value = section[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(section) {
+				return nil, false
+			}
+			value = section[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, value != nil
+}
+
+// setConfigValue set config value to flag.
+// Lists and maps are set item by item, so the default value is replaced rather than appended.
+func setConfigValue(f *pflag.Flag, value interface{}) error {
+	flagValue := f.Value
+	if pv, ok := flagValue.(interface{ elem() pflag.Value }); ok {
+		flagValue = pv.elem()
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		if sv, ok := flagValue.(pflag.SliceValue); ok {
+			return sv.Replace(items)
+		}
+		return flagValue.Set(strings.Join(items, ","))
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			err := flagValue.Set(fmt.Sprintf("%s=%v", key, v[key]))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return flagValue.Set(fmt.Sprint(v))
+	}
+}
+
+// loadConfigFile set flag values from config file.
+// Flags which value is set by flag parameter or env are skipped.
+func (b *binding) loadConfigFile(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	config, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range b.collections {
+		err := c.checkConfigItems(config)
+		if err != nil {
+			return errorw.Wrap(err, "invalid config items").WithField("path", path)
+		}
+	}
+
+	for _, v := range b.flags {
+		if v.ConfigFile {
+			continue
+		}
+
+		f := b.flagSet.Lookup(v.FullName)
+		if source := b.source(v.FullName); source == SourceFlag || source == SourceEnv {
+			continue
+		}
+
+		value, ok := lookupConfigValue(config, v)
+		if !ok {
+			continue
+		}
+		err := setConfigValue(f, value)
+		if err != nil {
+			return errorw.Wrap(err, "set config value").
+				WithField("name", v.FullName).
+				WithField("value", v.redact(value)).
+				WithField("path", path)
+		}
+		b.setSource(v.FullName, SourceConfig)
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package cmdutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testConfigFlag struct {
+	ConfigFile string          `flag:"config-file"`
+	Name       string          `flag:"env"`
+	Number     int             `flag:"env"`
+	Duration   time.Duration   `flag:""`
+	Slice      []string        `flag:""`
+	Map        map[string]int  `flag:""`
+	Nested     testConfigGroup `flag:""`
+	Flat       string          `flag:"flat name=flat-name"`
+}
+
+type testConfigGroup struct {
+	Enable bool   `flag:""`
+	Value  string `flag:""`
+}
+
+func writeTempFile(t *testing.T, name, content string) (path string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "cmdutil")
+	require.NoError(t, err)
+
+	path = filepath.Join(dir, name)
+	err = ioutil.WriteFile(path, []byte(content), 0600)
+	require.NoError(t, err)
+
+	return path, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func TestResolveFlagVariableWithConfigFile(t *testing.T) {
+	testCases := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `
+name: foo
+number: 42
+duration: 5s
+slice: [a, "b,c"]
+map:
+  x: 1
+  y: 2
+nested:
+  enable: true
+  value: bar
+flat-name: flat
+`,
+		},
+		{
+			name: "json",
+			file: "config.json",
+			content: `{
+  "name": "foo",
+  "number": 42,
+  "duration": "5s",
+  "slice": ["a", "b,c"],
+  "map": {"x": 1, "y": 2},
+  "nested": {"enable": true, "value": "bar"},
+  "flat-name": "flat"
+}`,
+		},
+		{
+			name: "toml",
+			file: "config.toml",
+			content: `
+name = "foo"
+number = 42
+duration = "5s"
+slice = ["a", "b,c"]
+flat-name = "flat"
+
+[map]
+x = 1
+y = 2
+
+[nested]
+enable = true
+value = "bar"
+`,
+		},
+		{
+			name: "full name keys",
+			file: "config.yaml",
+			content: `
+name: foo
+number: 42
+duration: 5s
+slice: [a, "b,c"]
+map: {x: 1, y: 2}
+nested-enable: true
+nested-value: bar
+flat-name: flat
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, cleanup := writeTempFile(t, tc.file, tc.content)
+			defer cleanup()
+
+			f := testConfigFlag{Slice: []string{"default"}}
+			cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+			err := ResolveFlagVariable(&cmd, &f)
+			require.NoError(t, err)
+
+			_, _, err = executeCommandC(&cmd, "--config-file", path)
+			require.NoError(t, err)
+
+			assert.Equal(t, testConfigFlag{
+				ConfigFile: path,
+				Name:       "foo",
+				Number:     42,
+				Duration:   5 * time.Second,
+				Slice:      []string{"a", "b,c"},
+				Map:        map[string]int{"x": 1, "y": 2},
+				Nested:     testConfigGroup{Enable: true, Value: "bar"},
+				Flat:       "flat",
+			}, f)
+		})
+	}
+}
+
+func TestResolveFlagVariableWithConfigFilePriority(t *testing.T) {
+	path, cleanup := writeTempFile(t, "config.yaml", "name: config\nnumber: 1\nduration: 1s\n")
+	defer cleanup()
+
+	// Monkey patch
+	monkey.Patch(os.Getenv, func(key string) string {
+		switch key {
+		case "CONFIG_FILE":
+			return path
+		case "NUMBER":
+			return "2"
+		case "LIST":
+			return "a,b"
+		}
+		return ""
+	})
+	defer monkey.Unpatch(os.Getenv)
+
+	f := struct {
+		ConfigFile string        `flag:"config-file env"`
+		Name       string        `flag:"env"`
+		Number     int           `flag:"env"`
+		Duration   time.Duration `flag:""`
+		Default    string        `flag:""`
+		List       []string      `flag:"env"`
+	}{
+		Default: "default",
+	}
+	cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+	err := ResolveFlagVariable(&cmd, &f)
+	require.NoError(t, err)
+
+	_, _, err = executeCommandC(&cmd, "--duration", "3s", "--list", "c")
+	require.NoError(t, err)
+
+	assert.Equal(t, "config", f.Name)
+	assert.Equal(t, 2, f.Number)
+	assert.Equal(t, 3*time.Second, f.Duration)
+	assert.Equal(t, "default", f.Default)
+	// Items of flag parameters replace items of env
+	assert.Equal(t, []string{"c"}, f.List)
+	assert.Equal(t, SourceFlag, Source(&f.List))
+}
+
+func TestResolveFlagVariableWithConfigFileAndPreRun(t *testing.T) {
+	path, cleanup := writeTempFile(t, "config.json", `{"name": "config"}`)
+	defer cleanup()
+
+	f := struct {
+		ConfigFile string `flag:"config-file"`
+		Name       string `flag:""`
+	}{
+		ConfigFile: path,
+	}
+
+	var name string
+	cmd := cobra.Command{
+		Run: func(*cobra.Command, []string) {},
+		PersistentPreRun: func(*cobra.Command, []string) {
+			name = f.Name
+		},
+	}
+	err := ResolveFlagVariable(&cmd, &f)
+	require.NoError(t, err)
+
+	_, _, err = executeCommandC(&cmd)
+	require.NoError(t, err)
+	assert.Equal(t, "config", name)
+}
+
+func TestResolveFlagVariableWithConfigFileInSubCommand(t *testing.T) {
+	path, cleanup := writeTempFile(t, "config.yaml", "name: config\n")
+	defer cleanup()
+
+	f := struct {
+		ConfigFile string `flag:"config-file"`
+		Name       string `flag:""`
+	}{}
+
+	var name string
+	root := &cobra.Command{Use: "root", Run: func(*cobra.Command, []string) {}}
+	sub := &cobra.Command{
+		Use: "sub",
+		PersistentPreRun: func(*cobra.Command, []string) {
+			name = f.Name
+		},
+		Run: func(*cobra.Command, []string) {},
+	}
+	root.AddCommand(sub)
+	err := ResolveFlagVariable(root, &f)
+	require.NoError(t, err)
+
+	_, _, err = executeCommandC(root, "sub", "--config-file", path)
+	require.NoError(t, err)
+	assert.Equal(t, "config", name)
+}
+
+func TestResolveFlagVariableWithInvalidConfigFile(t *testing.T) {
+	invalidYAML, cleanup := writeTempFile(t, "config.yaml", "name: [")
+	defer cleanup()
+	invalidValue, cleanup2 := writeTempFile(t, "config.yaml", "number: foo")
+	defer cleanup2()
+	unknownFormat, cleanup3 := writeTempFile(t, "config.ini", "number=1")
+	defer cleanup3()
+
+	testCases := []struct {
+		name          string
+		path          string
+		expectedError string
+	}{
+		{name: "not exists", path: "not-exists.yaml", expectedError: "read config file"},
+		{name: "invalid content", path: invalidYAML, expectedError: "decode config file"},
+		{name: "invalid value", path: invalidValue, expectedError: "set config value"},
+		{name: "not supported format", path: unknownFormat, expectedError: "not supported config file format: .ini"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := struct {
+				ConfigFile string `flag:"config-file"`
+				Number     int    `flag:""`
+			}{}
+			cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+			err := ResolveFlagVariable(&cmd, &f)
+			require.NoError(t, err)
+
+			_, _, err = executeCommandC(&cmd, "--config-file", tc.path)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectedError)
+		})
+	}
+}
+
+func TestResolveFlagVariableWithInvalidConfigFileFlag(t *testing.T) {
+	m := struct {
+		ConfigFile int `flag:"config-file"`
+	}{}
+	err := ResolveFlagVariable(&cobra.Command{}, &m)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "config file flag require string type")
+
+	n := struct {
+		ConfigFile  string `flag:"config-file"`
+		ConfigFile2 string `flag:"config-file"`
+	}{}
+	err = ResolveFlagVariable(&cobra.Command{}, &n)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicated config file flag")
+}
@@ -0,0 +1,341 @@
+package cmdutil
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/XSAM/go-hybrid/errorw"
+)
+
+// Formats of reference docs
+const (
+	DocFormatMarkdown = "markdown"
+	DocFormatYAML     = "yaml"
+	DocFormatEnv      = "env"
+)
+
+var docGenerators = map[string]func(w io.Writer, b *binding) error{
+	DocFormatMarkdown: genMarkdown,
+	DocFormatYAML:     genSampleYAML,
+	DocFormatEnv:      genEnvTemplate,
+}
+
+// GenMarkdown write a Markdown table of flags, env, types, defaults and usages
+// of the struct resolved by ResolveFlagVariable.
+func GenMarkdown(w io.Writer, f interface{}) error {
+	return genDoc(w, f, DocFormatMarkdown)
+}
+
+// GenSampleYAML write a commented sample config file of the struct resolved by ResolveFlagVariable.
+func GenSampleYAML(w io.Writer, f interface{}) error {
+	return genDoc(w, f, DocFormatYAML)
+}
+
+// GenEnvTemplate write a .env template of the struct resolved by ResolveFlagVariable.
+func GenEnvTemplate(w io.Writer, f interface{}) error {
+	return genDoc(w, f, DocFormatEnv)
+}
+
+func genDoc(w io.Writer, f interface{}, format string) error {
+	b := findBinding(f)
+	if b == nil {
+		return errorw.NewMessage("struct is not resolved by ResolveFlagVariable")
+	}
+	return docGenerators[format](w, b)
+}
+
+// ConfigDocCmd return a command which print reference docs of all structs
+// resolved by ResolveFlagVariable in the command tree.
+func ConfigDocCmd() *cobra.Command {
+	var format string
+	cmd := cobra.Command{
+		Use:   "config-doc",
+		Short: "Print reference of flags, env and config file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gen, ok := docGenerators[format]
+			if !ok {
+				return errorw.NewMessagef("not supported format: %s", format)
+			}
+
+			out := cmd.OutOrStdout()
+			for i, b := range commandBindings(cmd.Root()) {
+				if i > 0 {
+					fmt.Fprintln(out)
+				}
+				if format == DocFormatMarkdown {
+					fmt.Fprintf(out, "## %s\n\n", b.cmd.CommandPath())
+				} else {
+					fmt.Fprintf(out, "# %s\n", b.cmd.CommandPath())
+				}
+
+				err := gen(out, b)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", DocFormatMarkdown, "output format: markdown, yaml or env")
+	return &cmd
+}
+
+// commandBindings return bindings of commands in the tree, in the order of commands.
+func commandBindings(root *cobra.Command) []*binding {
+	bindings.RLock()
+	defer bindings.RUnlock()
+
+	var result []*binding
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		result = append(result, bindings.commands[cmd]...)
+		for _, c := range cmd.Commands() {
+			walk(c)
+		}
+	}
+	walk(root)
+	return result
+}
+
+// defaultValues return the default value of each flag.
+func (b *binding) defaultValues() []reflect.Value {
+	_, fields, _ := b.resolveDefaults()
+	result := make([]reflect.Value, 0, len(fields))
+	for _, v := range fields {
+		result = append(result, reflect.ValueOf(v.Pointer).Elem())
+	}
+	return result
+}
+
+// envNames return env names which are read by flag.
+func (v flag) envNames() []string {
+	var envs []string
+	if v.EnableEnv {
+		envs = append(envs, v.FullEnv)
+	}
+	if v.Secret {
+		envs = append(envs, v.FullEnv+secretFileSuffix)
+	}
+	return envs
+}
+
+// isList return true if the flag accepts a list of values.
+func (v flag) isList() bool {
+	return strings.HasSuffix(v.Type, "-slice") || strings.HasSuffix(v.Type, "-array")
+}
+
+// isMap return true if the flag accepts key=value pairs.
+func (v flag) isMap() bool {
+	return strings.Contains(v.Type, "-to-")
+}
+
+// elemType return the flag type of items of lists, or values of maps.
+// e.g. int for int-slice and string-to-int
+func (v flag) elemType() string {
+	switch {
+	case v.isList():
+		return strings.TrimSuffix(strings.TrimSuffix(v.Type, "-slice"), "-array")
+	case v.isMap():
+		return v.Type[strings.Index(v.Type, "-to-")+len("-to-"):]
+	}
+	return v.Type
+}
+
+// docValue return the value as items, nil pointer return nil.
+// Items of lists and maps are returned one by one, other values are returned as a single item.
+func (b *binding) docValue(v flag, value reflect.Value) []string {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if v.isSecret() && !value.IsZero() {
+		return []string{Redacted}
+	}
+
+	switch {
+	case v.isMap():
+		items := make([]string, 0, value.Len())
+		for _, key := range value.MapKeys() {
+			items = append(items, fmt.Sprintf("%v=%v", key.Interface(), value.MapIndex(key).Interface()))
+		}
+		sort.Strings(items)
+		return items
+	case v.isList():
+		return itemStrings(value)
+	}
+	// Format by flag value, e.g. durations are 1s rather than 1000000000
+	if fv, _, err := newFlagValue(v.Type, value.Addr().Interface()); err == nil {
+		return []string{fv.String()}
+	}
+	return []string{fmt.Sprint(value.Interface())}
+}
+
+func genMarkdown(w io.Writer, b *binding) error {
+	escape := strings.NewReplacer("|", `\|`, "\n", " ").Replace
+	code := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "`" + escape(s) + "`"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("| Flag | Env | Type | Default | Description |\n")
+	sb.WriteString("|------|-----|------|---------|-------------|\n")
+	defaults := b.defaultValues()
+	for i, v := range b.flags {
+		name := "--" + v.FullName
+		if v.Shorthand != "" {
+			name = "-" + v.Shorthand + ", " + name
+		}
+		envs := v.envNames()
+		for i := range envs {
+			envs[i] = code(envs[i])
+		}
+		defValue := strings.Join(b.docValue(v, defaults[i]), ",")
+
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s |\n",
+			code(name), strings.Join(envs, ", "), code(v.Type), code(defValue), escape(v.Usage))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func genSampleYAML(w io.Writer, b *binding) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	defaults := b.defaultValues()
+	for i, v := range b.flags {
+		if v.ConfigFile || len(v.Path) == 0 {
+			continue
+		}
+
+		// Find or create sections of path
+		section := root
+		for i, key := range v.Path[:len(v.Path)-1] {
+			s := v.slotAt(i + 1)
+			section = yamlSection(section, key, s != nil && s.collection.isSlice())
+		}
+
+		comment := fmt.Sprintf("--%s (%s)", v.FullName, v.Type)
+		if envs := v.envNames(); len(envs) > 0 {
+			comment += ", env " + strings.Join(envs, ", ")
+		}
+		if v.Usage != "" {
+			comment = v.Usage + "\n" + comment
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: v.Path[len(v.Path)-1], HeadComment: comment}
+		section.Content = append(section.Content, key, b.yamlValue(v, defaults[i]))
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	err := encoder.Encode(root)
+	if err != nil {
+		return errorw.Wrap(err, "encode sample config")
+	}
+	return encoder.Close()
+}
+
+// yamlSection return the section of key in section, create one if not found.
+// Section is a sequence of items if seq is true, otherwise a mapping.
+func yamlSection(section *yaml.Node, key string, seq bool) *yaml.Node {
+	kind := yaml.MappingNode
+	if seq {
+		kind = yaml.SequenceNode
+	}
+
+	// Items of sequence are found by index
+	if section.Kind == yaml.SequenceNode {
+		i, _ := strconv.Atoi(key)
+		for len(section.Content) <= i {
+			section.Content = append(section.Content, &yaml.Node{Kind: kind})
+		}
+		return section.Content[i]
+	}
+
+	for i := 0; i < len(section.Content); i += 2 {
+		if section.Content[i].Value == key && section.Content[i+1].Kind == kind {
+			return section.Content[i+1]
+		}
+	}
+
+	value := &yaml.Node{Kind: kind}
+	section.Content = append(section.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	return value
+}
+
+func (b *binding) yamlValue(v flag, value reflect.Value) *yaml.Node {
+	items := b.docValue(v, value)
+	switch {
+	// Never leak secrets to sample config
+	case v.isSecret():
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: ""}
+	case items == nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	case v.isMap():
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, item := range items {
+			kv := strings.SplitN(item, "=", 2)
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: kv[0]},
+				&yaml.Node{Kind: yaml.ScalarNode, Value: kv[1]},
+			)
+		}
+		return node
+	case v.isList():
+		node := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for _, item := range items {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: item})
+		}
+		return node
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: items[0]}
+}
+
+func genEnvTemplate(w io.Writer, b *binding) error {
+	var sb strings.Builder
+	defaults := b.defaultValues()
+	for i, v := range b.flags {
+		if !v.EnableEnv && !v.Secret {
+			continue
+		}
+
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		if v.Usage != "" {
+			fmt.Fprintf(&sb, "# %s\n", strings.ReplaceAll(v.Usage, "\n", "\n# "))
+		}
+		fmt.Fprintf(&sb, "# --%s (%s)\n", v.FullName, v.Type)
+
+		separator := ","
+		if v.EnvSplit != "" {
+			separator = v.EnvSplit
+		}
+		value := strings.Join(b.docValue(v, defaults[i]), separator)
+		if v.isSecret() {
+			value = ""
+		} else if strings.ContainsAny(value, " \t#\"'") {
+			value = strconv.Quote(value)
+		}
+		if v.EnableEnv {
+			fmt.Fprintf(&sb, "%s=%s\n", v.FullEnv, value)
+		}
+		if v.Secret {
+			fmt.Fprintf(&sb, "# %s%s=\n", v.FullEnv, secretFileSuffix)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,134 @@
+package cmdutil
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type testDocFlag struct {
+	ConfigFile string            `flag:"config-file env"`
+	Number     int               `flag:"env short=n" flag-usage:"the number | pipe"`
+	Tags       []string          `flag:"env env-split=;"`
+	Labels     map[string]string `flag:""`
+	Timeout    *time.Duration    `flag:""`
+	Password   Secret            `flag:"secret"`
+	Nested     struct {
+		Enable bool `flag:"env" flag-usage:"enable it"`
+	} `flag:""`
+}
+
+func newTestDocFlag(t *testing.T, cmd *cobra.Command) *testDocFlag {
+	f := testDocFlag{
+		Number:   42,
+		Tags:     []string{"a b", "c"},
+		Labels:   map[string]string{"k": "v"},
+		Password: "password",
+	}
+	require.NoError(t, ResolveFlagVariable(cmd, &f))
+	return &f
+}
+
+func TestGenMarkdown(t *testing.T) {
+	f := newTestDocFlag(t, &cobra.Command{})
+
+	var buf bytes.Buffer
+	require.NoError(t, GenMarkdown(&buf, f))
+	assert.Equal(t, "| Flag | Env | Type | Default | Description |\n"+
+		"|------|-----|------|---------|-------------|\n"+
+		"| `--config-file` | `CONFIG_FILE` | `string` |  |  |\n"+
+		"| `-n, --number` | `NUMBER` | `int` | `42` | the number \\| pipe |\n"+
+		"| `--tags` | `TAGS` | `string-slice` | `a b,c` |  |\n"+
+		"| `--labels` |  | `string-to-string` | `k=v` |  |\n"+
+		"| `--timeout` |  | `time.duration` |  |  |\n"+
+		"| `--password` | `PASSWORD_FILE` | `string` | `***` |  |\n"+
+		"| `--nested-enable` | `NESTED_ENABLE` | `bool` | `false` | enable it |\n", buf.String())
+}
+
+func TestGenSampleYAML(t *testing.T) {
+	f := newTestDocFlag(t, &cobra.Command{})
+
+	var buf bytes.Buffer
+	require.NoError(t, GenSampleYAML(&buf, f))
+	assert.Equal(t, `# the number | pipe
+# --number (int), env NUMBER
+number: 42
+# --tags (string-slice), env TAGS
+tags: [a b, c]
+# --labels (string-to-string)
+labels:
+  k: v
+# --timeout (time.duration)
+timeout: null
+# --password (string), env PASSWORD_FILE
+password:
+nested:
+  # enable it
+  # --nested-enable (bool), env NESTED_ENABLE
+  enable: false
+`, buf.String())
+
+	// Sample config can be decoded
+	var config map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &config))
+	assert.Equal(t, []interface{}{"a b", "c"}, config["tags"])
+}
+
+func TestGenEnvTemplate(t *testing.T) {
+	f := newTestDocFlag(t, &cobra.Command{})
+
+	var buf bytes.Buffer
+	require.NoError(t, GenEnvTemplate(&buf, f))
+	assert.Equal(t, `# --config-file (string)
+CONFIG_FILE=
+
+# the number | pipe
+# --number (int)
+NUMBER=42
+
+# --tags (string-slice)
+TAGS="a b;c"
+
+# --password (string)
+# PASSWORD_FILE=
+
+# enable it
+# --nested-enable (bool)
+NESTED_ENABLE=false
+`, buf.String())
+}
+
+func TestGenDocWithInvalid(t *testing.T) {
+	err := GenMarkdown(&bytes.Buffer{}, &testDocFlag{})
+	assert.Error(t, err)
+}
+
+func TestConfigDocCmd(t *testing.T) {
+	root := &cobra.Command{Use: "app"}
+	sub := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(sub, ConfigDocCmd())
+	require.NoError(t, ResolveFlagVariable(root, &struct {
+		Number int `flag:"env"`
+	}{}))
+	require.NoError(t, ResolveLocalFlagVariable(sub, &struct {
+		Name string `flag:"env"`
+	}{}))
+
+	_, output, err := executeCommandC(root, "config-doc")
+	require.NoError(t, err)
+	assert.Contains(t, output, "## app\n\n| Flag |")
+	assert.Contains(t, output, "## app sub\n\n| Flag |")
+
+	_, output, err = executeCommandC(root, "config-doc", "--format=env")
+	require.NoError(t, err)
+	assert.Equal(t, "# app\n# --number (int)\nNUMBER=0\n\n# app sub\n# --name (string)\nNAME=\n", output)
+
+	_, _, err = executeCommandC(root, "config-doc", "--format=foo")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported format: foo")
+}
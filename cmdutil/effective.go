@@ -0,0 +1,119 @@
+package cmdutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/XSAM/go-hybrid/errorw"
+)
+
+// Formats of effective config
+const (
+	ConfigFormatTable = "table"
+	ConfigFormatJSON  = "json"
+)
+
+// effectiveValue is the resolved value of a flag and where it comes from.
+type effectiveValue struct {
+	Command string     `json:"command"`
+	Flag    string     `json:"flag"`
+	Env     []string   `json:"env,omitempty"`
+	Value   string     `json:"value"`
+	Default string     `json:"default"`
+	Source  SourceType `json:"source"`
+}
+
+// ConfigCmd return a command which print the effective values of flags which the command inherits,
+// with env names, default values and sources. Values of secrets are redacted.
+// Values are loaded without validation, so invalid values can be printed.
+//
+//	app config --format=json
+func ConfigCmd() *cobra.Command {
+	var format string
+	cmd := cobra.Command{
+		Use:   "config",
+		Short: "Print effective values of flags, env and config file",
+		// Values are loaded by the command itself
+		Annotations: map[string]string{skipHookAnnotation: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var values []effectiveValue
+			for _, b := range inheritedBindings(cmd) {
+				err := b.loadValues()
+				if err != nil {
+					return err
+				}
+				values = append(values, b.effectiveValues()...)
+			}
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case ConfigFormatTable:
+				tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(tw, "COMMAND\tFLAG\tENV\tVALUE\tDEFAULT\tSOURCE")
+				for _, v := range values {
+					fmt.Fprintf(tw, "%s\t--%s\t%s\t%s\t%s\t%s\n",
+						v.Command, v.Flag, strings.Join(v.Env, ","), v.Value, v.Default, v.Source)
+				}
+				return tw.Flush()
+			case ConfigFormatJSON:
+				if values == nil {
+					values = []effectiveValue{}
+				}
+				data, err := json.MarshalIndent(values, "", "  ")
+				if err != nil {
+					return errorw.Wrap(err, "marshal effective config")
+				}
+				fmt.Fprintln(out, string(data))
+				return nil
+			}
+			return errorw.NewMessagef("not supported format: %s", format)
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", ConfigFormatTable, "output format: table or json")
+	return &cmd
+}
+
+// inheritedBindings return bindings which values are loaded before running cmd,
+// persistent flags of parents and flags of cmd itself, from the root.
+func inheritedBindings(cmd *cobra.Command) []*binding {
+	bindings.RLock()
+	defer bindings.RUnlock()
+
+	var result []*binding
+	for c := cmd; c != nil; c = c.Parent() {
+		var list []*binding
+		for _, b := range bindings.commands[c] {
+			if c == cmd || b.flagSet == c.PersistentFlags() {
+				list = append(list, b)
+			}
+		}
+		result = append(list, result...)
+	}
+	return result
+}
+
+// effectiveValues return the current values of flags.
+func (b *binding) effectiveValues() []effectiveValue {
+	defaults := b.defaultValues()
+	result := make([]effectiveValue, 0, len(b.flags))
+	for i, v := range b.flags {
+		// Skip unused items of slices and maps
+		if !v.active() {
+			continue
+		}
+		result = append(result, effectiveValue{
+			Command: b.cmd.CommandPath(),
+			Flag:    v.FullName,
+			Env:     v.envNames(),
+			Value:   strings.Join(b.docValue(v, reflect.ValueOf(v.Pointer).Elem()), ","),
+			Default: strings.Join(b.docValue(v, defaults[i]), ","),
+			Source:  b.source(v.FullName),
+		})
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package cmdutil
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigCmd(t *testing.T) {
+	// Monkey patch
+	monkey.Patch(os.Getenv, func(key string) string {
+		switch key {
+		case "NUMBER":
+			return "7"
+		case "PASSWORD":
+			return "password"
+		}
+		return ""
+	})
+	defer monkey.Unpatch(os.Getenv)
+
+	dir, err := ioutil.TempDir("", "cmdutil")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("timeout: 3s\n"), 0600))
+
+	root := &cobra.Command{Use: "app"}
+	sub := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(sub, ConfigCmd())
+	require.NoError(t, ResolveFlagVariable(root, &struct {
+		Config   string        `flag:"config-file"`
+		Number   int           `flag:"env"`
+		Timeout  time.Duration `flag:""`
+		Name     string        `flag:""`
+		Password Secret        `flag:"env secret"`
+	}{Number: 1, Timeout: time.Second}, WithEnvPrefix("")))
+	// Local flags of other commands are not loaded
+	require.NoError(t, ResolveLocalFlagVariable(sub, &struct {
+		Local string `flag:""`
+	}{}))
+
+	_, output, err := executeCommandC(root, "config", "--format=json", "--config", path, "--name=foo")
+	require.NoError(t, err)
+	var values []effectiveValue
+	require.NoError(t, json.Unmarshal([]byte(output), &values))
+	assert.Equal(t, []effectiveValue{
+		{Command: "app", Flag: "config", Value: path, Source: SourceFlag},
+		{Command: "app", Flag: "number", Env: []string{"NUMBER"}, Value: "7", Default: "1", Source: SourceEnv},
+		{Command: "app", Flag: "timeout", Value: "3s", Default: "1s", Source: SourceConfig},
+		{Command: "app", Flag: "name", Value: "foo", Source: SourceFlag},
+		{Command: "app", Flag: "password", Env: []string{"PASSWORD", "PASSWORD_FILE"}, Value: Redacted, Source: SourceEnv},
+	}, values)
+
+	_, output, err = executeCommandC(root, "config", "--format=table")
+	require.NoError(t, err)
+	assert.Contains(t, output, "COMMAND  FLAG")
+	assert.Contains(t, output, "--number")
+	assert.NotContains(t, output, "password\n")
+	assert.NotContains(t, output, "--local")
+
+	_, _, err = executeCommandC(root, "config", "--format=foo")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported format: foo")
+}
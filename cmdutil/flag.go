@@ -6,11 +6,14 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
 
 	"github.com/XSAM/go-hybrid/errorw"
+	"github.com/XSAM/go-hybrid/log"
 )
 
 type flags []flag
@@ -24,6 +27,12 @@ type flag struct {
 	Shorthand string
 	Usage     string
 	Required  bool
+	// Hide flag in help
+	Hidden bool
+	// Deprecation message, deprecated flags are hidden in help
+	Deprecated string
+	// Old full names, which set the same field
+	Aliases []string
 
 	// Enable env
 	EnableEnv bool
@@ -31,6 +40,30 @@ type flag struct {
 	// e.g. PREFIX_FOO
 	FullEnv  string
 	EnvSplit string
+	// Explicit env name from tag, which is used as it is
+	EnvName string
+	// Deprecated env names, only used if FullEnv isn't set
+	EnvAliases []string
+	// Read value from the file of <FullEnv>_FILE, and redact value in Dump, Redact, help, docs and errors
+	Secret bool
+
+	// Keys of config file with struct hierarchy
+	// e.g. [prefix foo]
+	Path []string
+	// Go field with struct hierarchy
+	// e.g. Prefix.Foo
+	Field string
+	// Use value as config file path
+	ConfigFile bool
+	// Shell completion of values, file or dir
+	Complete string
+	// Extensions of files to complete, without dot
+	Extensions []string
+
+	Rules rules
+
+	// Items of slices or maps of structs which the flag belongs to, from outer to inner
+	Slots []*slot
 
 	Type    string
 	Value   interface{}
@@ -46,71 +79,63 @@ func toSnake(name string) string {
 	return strings.ToLower(snake)
 }
 
+// resolveCobraType return the flag type name of field.
+// The priority is: type of tag > registered type > pflag.Value > encoding.TextUnmarshaler > type generated by kind.
 func resolveCobraType(field reflect.StructField, tag flagTag) string {
 	if tag.flagType != "" {
 		return tag.flagType
 	}
+	return resolveType(field.Type)
+}
 
-	switch field.Type.Kind() {
-	case reflect.Int:
-		return "int"
-	case reflect.Int32:
-		return "int32"
-	case reflect.Int64:
-		switch field.Type.String() {
-		case "time.Duration":
-			return "time.duration"
-		default:
-			return "int64"
-		}
-	case reflect.Bool:
-		return "bool"
-	case reflect.String:
-		return "string"
-	case reflect.Slice, reflect.Array:
-		switch field.Type.Elem().Kind() {
-		case reflect.Int:
-			return "int-slice"
-		case reflect.Int64:
-			switch field.Type.Elem().String() {
-			case "time.Duration":
-				return "time.duration-slice"
-			default:
-				return "int64-slice"
-			}
-		case reflect.Bool:
-			return "bool-slice"
-		case reflect.String:
-			return "string-slice"
-		}
-	case reflect.Map:
-		var keyType, valueType string
-		switch field.Type.Key().Kind() {
-		case reflect.Int:
-			keyType = "int"
-		case reflect.Bool:
-			keyType = "bool"
-		case reflect.String:
-			keyType = "string"
-		}
+func resolveType(t reflect.Type) string {
+	if name, ok := flagTypeNames[t]; ok {
+		return name
+	}
+	pt := reflect.PtrTo(t)
+	if pt.Implements(pflagValueType) {
+		return "pflag.value"
+	}
+	if pt.Implements(textUnmarshalerType) {
+		return "encoding.text"
+	}
+	// Pointer field use the type of its element
+	if t.Kind() == reflect.Ptr {
+		return resolveType(t.Elem())
+	}
+	return typeName(t)
+}
 
-		switch field.Type.Elem().Kind() {
-		case reflect.Int:
-			valueType = "int"
-		case reflect.Bool:
-			valueType = "bool"
-		case reflect.String:
-			valueType = "string"
-		}
+// resolveFlags return flags of struct, slices and maps of structs have flags of current items.
+func resolveFlags(obj interface{}, flags flags, namePrefix string, pathPrefix []string, depth int) flags {
+	return resolver{}.resolve(obj, flags, namePrefix, pathPrefix, "", nil, depth)
+}
 
-		if keyType != "" && valueType != "" {
-			return fmt.Sprintf("%s-to-%s", keyType, valueType)
-		}
+// resolver resolve flags of struct.
+type resolver struct {
+	mode slotMode
+	// Find items from env in slotGrow mode
+	options *options
+	// Collections created by resolve are appended if it isn't nil, including collections without items
+	collections *[]*collection
+}
+
+// resolverOf return the resolver of struct which f points to, which uses the options of its binding.
+func resolverOf(f interface{}, mode slotMode) resolver {
+	r := resolver{mode: mode}
+	if b := findBinding(f); b != nil {
+		r.options = &b.options
 	}
-	return ""
+	return r
+}
+
+// embeddedPrefix return true if embedded structs use their type names as prefix.
+func (r resolver) embeddedPrefix() bool {
+	return r.options != nil && r.options.embeddedPrefix
 }
 
-func resolveFlags(obj interface{}, flags flags, namePrefix string, depth int) flags {
+func (r resolver) resolve(obj interface{}, flags flags, namePrefix string, pathPrefix []string, fieldPrefix string,
+	slots []*slot, depth int) flags {
 	if depth > FlagMaxDepth {
 		return flags
 	}
@@ -131,30 +156,66 @@ func resolveFlags(obj interface{}, flags flags, namePrefix string, depth int) fl
 			continue
 		}
 
-		tag := resolveFlagTag(field.Tag)
+		// Invalid tags are reported by checkTags before resolving
+		tag, _ := resolveFlagTag(field.Tag)
 		if !tag.enable {
 			continue
 		}
+		flagType := resolveCobraType(field, tag)
 		name := resolveFieldName(field, tag)
+		// Fields of embedded structs are promoted, unless the name is given by tag
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && flagType == "" &&
+			tag.name == nil && !r.embeddedPrefix() {
+			name = ""
+		}
 		fullName := genFullName(tag.flat, namePrefix, name)
+		path := genPath(tag.flat, pathPrefix, name)
+		fieldName := field.Name
+		if fieldPrefix != "" {
+			fieldName = fieldPrefix + "." + field.Name
+		}
 
-		switch field.Type.Kind() {
-		case reflect.Struct:
-			flags = resolveFlags(v.Field(i).Addr().Interface(), flags, fullName, depth+1)
+		switch {
+		// Struct which isn't a flag type has its own flags
+		case field.Type.Kind() == reflect.Struct && flagType == "":
+			flags = r.resolve(v.Field(i).Addr().Interface(), flags, fullName, path, fieldName, slots, depth+1)
+		// Each item of slices and maps of structs has its own flags
+		case isCollection(field.Type) && flagType == "":
+			c := r.newCollection(v.Field(i), tag, fullName, path)
+			for _, s := range c.slots {
+				flags = r.resolve(s.value.Interface(), flags, fullName+"-"+s.key, appendString(path, s.key),
+					fmt.Sprintf("%s[%s]", fieldName, s.key), appendSlot(slots, s), depth+1)
+			}
 		default:
-			flagType := resolveCobraType(field, tag)
+			var aliases []string
+			for _, alias := range tag.aliases {
+				aliases = append(aliases, genFullName(tag.flat, namePrefix, alias))
+			}
 			flag := flag{
-				Name:      name,
-				FullName:  fullName,
-				Shorthand: tag.shorthand,
-				Usage:     tag.usage,
-				Required:  tag.required,
-				EnableEnv: tag.enableEnv,
-				FullEnv:   genEnv(fullName),
-				EnvSplit:  tag.envSplit,
-				Type:      flagType,
-				Value:     v.Field(i).Interface(),
-				Pointer:   v.Field(i).Addr().Interface(),
+				Name:       name,
+				FullName:   fullName,
+				Shorthand:  tag.shorthand,
+				Usage:      tag.usage,
+				Required:   tag.required,
+				Hidden:     tag.hidden,
+				Deprecated: tag.deprecated,
+				Aliases:    aliases,
+				EnableEnv:  tag.enableEnv,
+				FullEnv:    genEnv(fullName),
+				EnvSplit:   tag.envSplit,
+				EnvName:    tag.envName,
+				EnvAliases: tag.envAliases,
+				Secret:     tag.secret,
+				Path:       path,
+				Field:      fieldName,
+				ConfigFile: tag.configFile,
+				Complete:   tag.complete,
+				Extensions: tag.extensions,
+				Rules:      tag.rules,
+				Type:       flagType,
+				Value:      v.Field(i).Interface(),
+				Pointer:    v.Field(i).Addr().Interface(),
+				Slots:      slots,
 			}
 
 			flags = append(flags, flag)
@@ -178,7 +239,9 @@ func genFullName(flat bool, namePrefix, name string) string {
 	}
 
 	var fullName string
-	if namePrefix != "" {
+	if name == "" {
+		fullName = namePrefix
+	} else if namePrefix != "" {
 		fullName = namePrefix + "-" + name
 	} else {
 		fullName = name
@@ -186,91 +249,294 @@ func genFullName(flat bool, namePrefix, name string) string {
 	return fullName
 }
 
+// genPath append name to the config keys of parent struct.
+// Empty name means the struct doesn't have its own section.
+func genPath(flat bool, pathPrefix []string, name string) []string {
+	var path []string
+	if !flat {
+		path = append(path, pathPrefix...)
+	}
+	if name != "" {
+		path = append(path, name)
+	}
+	return path
+}
+
 // genEnv replace "-" to "_", and upper all character
 func genEnv(name string) string {
 	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
 }
 
-// ResolveFlagVariable register persistent flags and env via tags in struct
-func ResolveFlagVariable(cmd *cobra.Command, f interface{}) (err error) {
-	t := reflect.TypeOf(f)
-	if t.Kind() != reflect.Ptr {
-		return errorw.NewMessage("flag variable require pointer type")
-	}
+// ResolveFlagVariable register persistent flags and env via tags in struct.
+// If a field is tagged with config-file, values in that config file are loaded
+// before running the command. Then values are checked by validation rules in tags.
+// Required flags can be satisfied by flag parameters, env or config file.
+// A struct resolved again, e.g. for commands re-created by tests, replaces its previous binding.
+//
+// Env names are prefixed with the upper-cased app name by default, use options to change it.
+func ResolveFlagVariable(cmd *cobra.Command, f interface{}, opts ...Option) (err error) {
+	return resolveFlagVariable(cmd, f, false, newOptions(opts))
+}
 
-	var flags flags
-	flags = resolveFlags(f, flags, "", 1)
+// ResolveLocalFlagVariable register local flags and env via tags in struct.
+// Unlike ResolveFlagVariable, flags are only available in cmd rather than its sub commands,
+// and values are loaded and validated in the PreRunE of cmd.
+func ResolveLocalFlagVariable(cmd *cobra.Command, f interface{}, opts ...Option) (err error) {
+	return resolveFlagVariable(cmd, f, true, newOptions(opts))
+}
 
-	// Check full name conflict
-	set := make(map[string]struct{})
-	for _, v := range flags {
-		if _, ok := set[v.FullName]; ok {
-			return errorw.NewMessagef("duplicated flag full name: %s", v.FullName)
-		} else {
-			set[v.FullName] = struct{}{}
+func resolveFlagVariable(cmd *cobra.Command, f interface{}, local bool, o options) (err error) {
+	b, pflags, err := resolveBinding(f, o)
+	if err != nil {
+		return err
+	}
+	flagSet := cmd.PersistentFlags()
+	if local {
+		flagSet = cmd.Flags()
+	}
+	err = b.register(flagSet, pflags)
+	if err != nil {
+		return err
+	}
+	b.cmd = cmd
+
+	for _, v := range b.flags {
+		fn, _ := v.completion()
+		if fn == nil {
+			continue
+		}
+		err = cmd.RegisterFlagCompletionFunc(v.FullName, fn)
+		if err != nil {
+			return errorw.Wrap(err, "register flag completion").WithField("name", v.FullName)
 		}
 	}
 
-	// Register flags to cobra
-	for _, v := range flags {
-		switch v.Type {
-		case "bool":
-			cmd.PersistentFlags().BoolVarP(v.Pointer.(*bool), v.FullName, v.Shorthand, v.Value.(bool), v.Usage)
-		case "string":
-			cmd.PersistentFlags().StringVarP(v.Pointer.(*string), v.FullName, v.Shorthand, v.Value.(string), v.Usage)
-		case "int":
-			cmd.PersistentFlags().IntVarP(v.Pointer.(*int), v.FullName, v.Shorthand, v.Value.(int), v.Usage)
-		case "int32":
-			cmd.PersistentFlags().Int32VarP(v.Pointer.(*int32), v.FullName, v.Shorthand, v.Value.(int32), v.Usage)
-		case "int64":
-			cmd.PersistentFlags().Int64VarP(v.Pointer.(*int64), v.FullName, v.Shorthand, v.Value.(int64), v.Usage)
-		case "time.duration":
-			cmd.PersistentFlags().DurationVarP(v.Pointer.(*time.Duration), v.FullName, v.Shorthand, v.Value.(time.Duration), v.Usage)
-		case "int-slice":
-			cmd.PersistentFlags().IntSliceVarP(v.Pointer.(*[]int), v.FullName, v.Shorthand, v.Value.([]int), v.Usage)
-		case "time.duration-slice":
-			cmd.PersistentFlags().DurationSliceVarP(v.Pointer.(*[]time.Duration), v.FullName, v.Shorthand, v.Value.([]time.Duration), v.Usage)
-		case "string-slice":
-			cmd.PersistentFlags().StringSliceVarP(v.Pointer.(*[]string), v.FullName, v.Shorthand, v.Value.([]string), v.Usage)
-		case "bool-slice":
-			cmd.PersistentFlags().BoolSliceVarP(v.Pointer.(*[]bool), v.FullName, v.Shorthand, v.Value.([]bool), v.Usage)
-		case "string-to-string":
-			cmd.PersistentFlags().StringToStringVarP(v.Pointer.(*map[string]string), v.FullName, v.Shorthand, v.Value.(map[string]string), v.Usage)
-		case "string-to-int":
-			cmd.PersistentFlags().StringToIntVarP(v.Pointer.(*map[string]int), v.FullName, v.Shorthand, v.Value.(map[string]int), v.Usage)
-		default:
-			return errorw.NewMessagef("not supported flag type: %s", v.Type)
+	// Load config file and validate values after flag parameters are parsed
+	hook := func(c *cobra.Command, _ []string) error {
+		if skipHook(c) {
+			return nil
 		}
+		return b.load()
+	}
+	if local {
+		chainPreRunE(&cmd.PreRunE, &cmd.PreRun, hook)
+	} else {
+		chainPersistentPreRunE(cmd, hook)
+	}
+
+	addBinding(b)
+	return nil
+}
 
-		if v.Required {
-			// Logically, it won't cause errors.
-			cmd.MarkPersistentFlagRequired(v.FullName) // nolint
+// checkKind return error if the field is an interface, func, chan or pointer to struct without flag type.
+func (v flag) checkKind() error {
+	if v.Type != "" {
+		return nil
+	}
+	switch t := reflect.TypeOf(v.Pointer).Elem(); t.Kind() {
+	case reflect.Interface, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return errorw.NewMessagef("%s field cannot be a flag, register a flag type or remove the flag tag", t.Kind()).
+			WithField("name", v.FullName).
+			WithField("field", v.Field)
+	case reflect.Ptr:
+		if t.Elem().Kind() == reflect.Struct {
+			return errorw.NewMessage("pointer to struct field cannot be a flag, use a struct field or register a flag type").
+				WithField("name", v.FullName).
+				WithField("field", v.Field).
+				WithField("kind", t.Elem().Kind().String())
 		}
 	}
+	return nil
+}
 
-	// Register env
-	err = registerEnv(cmd, flags)
+// newFlag create a pflag.Flag which stores value in the field of the flag.
+func newFlag(v flag) (*pflag.Flag, error) {
+	value, ft, err := newFlagValue(v.Type, v.Pointer)
 	if err != nil {
-		return errorw.Wrap(err, "register env value")
+		return nil, errorw.Wrap(err, "create flag value").WithField("name", v.FullName).WithField("field", v.Field)
+	}
+
+	f := &pflag.Flag{
+		Name:        v.FullName,
+		Shorthand:   v.Shorthand,
+		Usage:       v.Usage,
+		Value:       value,
+		DefValue:    value.String(),
+		NoOptDefVal: ft.noOptDefVal,
+		Hidden:      v.Hidden,
+		Deprecated:  v.Deprecated,
+	}
+	// Help prints the default value, which is redacted for secrets
+	if v.isSecret() && f.DefValue != "" {
+		f.DefValue = Redacted
+	}
+	// Allow bool flags to be used without value. e.g. --foo
+	if bv, ok := value.(interface{ IsBoolFlag() bool }); ok && bv.IsBoolFlag() && f.NoOptDefVal == "" {
+		f.NoOptDefVal = "true"
+	}
+	return f, nil
+}
+
+// newAliasFlags create deprecated flags of old names, which set the same field.
+// pflag prints a deprecation message pointing to the new name when an old name is used.
+func newAliasFlags(v flag) ([]*pflag.Flag, error) {
+	result := make([]*pflag.Flag, 0, len(v.Aliases))
+	for _, alias := range v.Aliases {
+		f, err := newFlag(v)
+		if err != nil {
+			return nil, err
+		}
+		f.Name = alias
+		f.Shorthand = ""
+		f.Hidden = true
+		f.Deprecated = fmt.Sprintf("use --%s instead", v.FullName)
+		f.Usage = "deprecated, " + f.Deprecated
+		result = append(result, f)
+	}
+	return result, nil
+}
+
+// checkAliases treat the value as set by flag parameter for each old name which is used.
+// Flag sets of the standard library don't print deprecation messages, so a warning is logged for them.
+func (b *binding) checkAliases() {
+	for _, v := range b.flags {
+		for _, alias := range v.Aliases {
+			if f := b.flagSet.Lookup(alias); f == nil || !f.Changed {
+				continue
+			}
+			if b.goFlagSet != nil {
+				log.BgLogger().Warn("flag is deprecated, please use the new one instead",
+					zap.String("flag", alias), zap.String("replacement", v.FullName))
+			}
+			b.setSource(v.FullName, SourceFlag)
+		}
+	}
+}
+
+// chainPreRunE run fn before the pre-run of command, which is given by the pointers of command fields.
+// e.g. chainPreRunE(&cmd.PreRunE, &cmd.PreRun, fn)
+//
+// Cobra only runs the first persistent pre-run found from the executed command to the root,
+// use chainPersistentPreRunE for persistent pre-run instead.
+func chainPreRunE(preRunE *func(*cobra.Command, []string) error, preRun *func(*cobra.Command, []string),
+	fn func(cmd *cobra.Command, args []string) error) {
+	next := *preRunE
+	*preRunE = func(c *cobra.Command, args []string) error {
+		if err := fn(c, args); err != nil {
+			return err
+		}
+
+		if next != nil {
+			return next(c, args)
+		}
+		// Pre-run is ignored by cobra when pre-run with error exists
+		if *preRun != nil {
+			(*preRun)(c, args)
+		}
+		return nil
+	}
+}
+
+// persistentHooks are functions chained by chainPersistentPreRunE.
+var persistentHooks struct {
+	sync.Mutex
+	once sync.Once
+	// In the order they are chained
+	list []*persistentHook
+}
+
+type persistentHook struct {
+	cmd *cobra.Command
+	fn  func(cmd *cobra.Command, args []string) error
+	// Sub commands which fn is chained to
+	chained map[*cobra.Command]bool
+}
+
+// chainPersistentPreRunE run fn before the persistent pre-run of cmd and all its sub commands.
+// Cobra only runs the first persistent pre-run found from the executed command to the root,
+// so fn is chained to sub commands which have their own persistent pre-run as well,
+// by a cobra initializer before pre-runs are executed.
+func chainPersistentPreRunE(cmd *cobra.Command, fn func(cmd *cobra.Command, args []string) error) {
+	chainPreRunE(&cmd.PersistentPreRunE, &cmd.PersistentPreRun, fn)
+
+	persistentHooks.Lock()
+	defer persistentHooks.Unlock()
+	persistentHooks.list = append(persistentHooks.list, &persistentHook{
+		cmd:     cmd,
+		fn:      fn,
+		chained: map[*cobra.Command]bool{cmd: true},
+	})
+	persistentHooks.once.Do(func() {
+		cobra.OnInitialize(inheritPersistentHooks)
+	})
+}
+
+// removePersistentHooks stop chaining hooks of cmd to its sub commands.
+func removePersistentHooks(cmd *cobra.Command) {
+	persistentHooks.Lock()
+	defer persistentHooks.Unlock()
+
+	var list []*persistentHook
+	for _, h := range persistentHooks.list {
+		if h.cmd != cmd {
+			list = append(list, h)
+		}
+	}
+	persistentHooks.list = list
+}
+
+// inheritPersistentHooks chain persistent hooks to sub commands which have their own persistent pre-run,
+// hooks are chained in the order they are registered, and once for each sub command.
+func inheritPersistentHooks() {
+	persistentHooks.Lock()
+	defer persistentHooks.Unlock()
+
+	for _, h := range persistentHooks.list {
+		h.chain(h.cmd)
+	}
+}
+
+func (h *persistentHook) chain(parent *cobra.Command) {
+	for _, c := range parent.Commands() {
+		// Sub commands which are added to other commands later belong to them
+		if c.Parent() != parent {
+			continue
+		}
+		if !h.chained[c] && (c.PersistentPreRunE != nil || c.PersistentPreRun != nil) {
+			chainPreRunE(&c.PersistentPreRunE, &c.PersistentPreRun, h.fn)
+			h.chained[c] = true
+		}
+		h.chain(c)
 	}
-	return nil
 }
 
 // Register env
-func registerEnv(cmd *cobra.Command, flags flags) error {
-	for _, v := range flags {
-		if !v.EnableEnv {
+func (b *binding) registerEnv() error {
+	for _, v := range b.flags {
+		if !v.EnableEnv && !v.Secret {
 			continue
 		}
 
-		f := cmd.Flag(v.FullName)
+		f := b.flagSet.Lookup(v.FullName)
 
+		var envs []string
+		if v.EnableEnv {
+			envs = append(envs, v.FullEnv)
+		}
+		if v.Secret {
+			envs = append(envs, v.FullEnv+secretFileSuffix)
+		}
 		if f.Usage == "" {
-			f.Usage = fmt.Sprintf("[env %v]", v.FullEnv)
+			f.Usage = fmt.Sprintf("[env %v]", strings.Join(envs, ", "))
 		} else {
-			f.Usage = fmt.Sprintf("%v [env %v]", f.Usage, v.FullEnv)
+			f.Usage = fmt.Sprintf("%v [env %v]", f.Usage, strings.Join(envs, ", "))
 		}
-		if value := os.Getenv(v.FullEnv); value != "" {
+
+		value, err := v.lookupEnv()
+		if err != nil {
+			return errorw.Wrap(err, "lookup env value").WithField("name", v.FullName)
+		}
+		if value != "" {
 			// Customized env separator
 			if v.EnvSplit != "" {
 				strings.Split(value, v.EnvSplit)
@@ -280,7 +546,7 @@ func registerEnv(cmd *cobra.Command, flags flags) error {
 					if err != nil {
 						return errorw.Wrap(err, "set env value with delimiter").
 							WithField("name", v.FullName).
-							WithField("value", value).
+							WithField("value", v.redact(value)).
 							WithField("delimiter", v.EnvSplit)
 					}
 				}
@@ -289,10 +555,69 @@ func registerEnv(cmd *cobra.Command, flags flags) error {
 				if err != nil {
 					return errorw.Wrap(err, "set env value").
 						WithField("name", v.FullName).
-						WithField("value", value)
+						WithField("value", v.redact(value))
 				}
 			}
+			b.setSource(v.FullName, SourceEnv)
+			if sv, ok := f.Value.(pflag.SliceValue); ok {
+				b.envItems[v.FullName] = len(sv.GetSlice())
+			}
+		}
+	}
+	return nil
+}
+
+// replaceEnvItems remove items set by env from slices which are set by flag parameters,
+// since pflag appends items of flag parameters to the items set by env.
+func (b *binding) replaceEnvItems() error {
+	for name, n := range b.envItems {
+		f := b.flagSet.Lookup(name)
+		if !f.Changed {
+			continue
+		}
+
+		sv := f.Value.(pflag.SliceValue)
+		err := sv.Replace(sv.GetSlice()[n:])
+		if err != nil {
+			return errorw.Wrap(err, "replace env items").WithField("name", name)
 		}
+		delete(b.envItems, name)
 	}
 	return nil
 }
+
+const secretFileSuffix = "_FILE"
+
+// lookupEnv return the value of env.
+// Aliases are used if env isn't set, and a deprecation warning is logged.
+// Secret reads the file of <env>_FILE at last.
+func (v flag) lookupEnv() (string, error) {
+	if v.EnableEnv {
+		if value := os.Getenv(v.FullEnv); value != "" {
+			return value, nil
+		}
+
+		for _, alias := range v.EnvAliases {
+			if value := os.Getenv(alias); value != "" {
+				log.BgLogger().Warn("env is deprecated, please use the new one instead",
+					zap.String("env", alias), zap.String("replacement", v.FullEnv))
+				return value, nil
+			}
+		}
+	}
+
+	if v.Secret {
+		if path := os.Getenv(v.FullEnv + secretFileSuffix); path != "" {
+			return readSecretFile(path)
+		}
+	}
+	return "", nil
+}
+
+// redact return redacted value for secret flags.
+func (v flag) redact(value interface{}) interface{} {
+	if v.isSecret() {
+		return Redacted
+	}
+	return value
+}
@@ -1,6 +1,7 @@
 package cmdutil
 
 import (
+	"net"
 	"os"
 	"reflect"
 	"testing"
@@ -10,6 +11,12 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/XSAM/go-hybrid/errorw"
+	"github.com/XSAM/go-hybrid/log"
+	"github.com/XSAM/go-hybrid/metadata"
 )
 
 type testFlag struct {
@@ -85,40 +92,40 @@ func Test_resolveFlags(t *testing.T) {
 	}
 
 	var result flags
-	result = resolveFlags(&f, result, "", 0)
+	result = resolveFlags(&f, result, "", nil, 0)
 	assert.Equal(t, flags{
-		{Name: "string", FullName: "string", FullEnv: "STRING", EnableEnv: true, Shorthand: "s", Usage: "foo", EnvSplit: ",", Type: "string", Value: "normal", Pointer: &f.String},
-		{Name: "array", FullName: "array", FullEnv: "ARRAY", Type: "string-slice", Value: []string{"foo"}, Pointer: &f.Array},
-		{Name: "map", FullName: "map", FullEnv: "MAP", Type: "string-to-string", Value: map[string]string{"foo": "bar"}, Pointer: &f.Map},
-
-		{Name: "new-name", FullName: "new-name", FullEnv: "NEW_NAME", Type: "string", Value: "", Pointer: &f.OverwriteName},
-		{Name: "prefix", FullName: "new-prefix-prefix", FullEnv: "NEW_PREFIX_PREFIX", Type: "string", Value: "", Pointer: &f.OverwritePrefix.Prefix},
-
-		{Name: "required", FullName: "required", FullEnv: "REQUIRED", Type: "string", Value: "", Required: true, Pointer: &f.Required},
-
-		{Name: "short", FullName: "short", FullEnv: "SHORT", Type: "string", Value: "", Pointer: &f.Short},
-		{Name: "short-env", FullName: "short-env", FullEnv: "SHORT_ENV", EnableEnv: true, Type: "string", Value: "", Pointer: &f.ShortEnv},
-		{Name: "short-flat", FullName: "short-flat", FullEnv: "SHORT_FLAT", Type: "string", Value: "", Pointer: &f.ShortFlat},
-		{Name: "short-usage", FullName: "short-usage", FullEnv: "SHORT_USAGE", Type: "string", Value: "", Usage: "foo", Pointer: &f.ShortUsage},
-
-		{Name: "inline", FullName: "test-inline-inline", FullEnv: "TEST_INLINE_INLINE", Type: "string", Value: "", Pointer: &f.TestInline.Inline},
-
-		{Name: "flat", FullName: "flat", FullEnv: "FLAT", Type: "string", Value: "", Pointer: &f.Flat.Flat},
-
-		{Name: "flat2", FullName: "flat2", FullEnv: "FLAT2", Type: "string", Value: "", Pointer: &f.Flat2.Flat2},
-
-		{Name: "int", FullName: "type-int", FullEnv: "TYPE_INT", Type: "int", Value: 0, Pointer: &f.Type.Int},
-		{Name: "int32", FullName: "type-int32", FullEnv: "TYPE_INT32", Type: "int32", Value: int32(0), Pointer: &f.Type.Int32},
-		{Name: "int64", FullName: "type-int64", FullEnv: "TYPE_INT64", Type: "int64", Value: int64(0), Pointer: &f.Type.Int64},
-		{Name: "duration", FullName: "type-duration", FullEnv: "TYPE_DURATION", Type: "time.duration", Value: time.Duration(0), Pointer: &f.Type.Duration},
-		{Name: "string", FullName: "type-string", FullEnv: "TYPE_STRING", Type: "string", Value: "", Pointer: &f.Type.String},
-		{Name: "bool", FullName: "type-bool", FullEnv: "TYPE_BOOL", Type: "bool", Value: false, Pointer: &f.Type.Bool},
-		{Name: "int-slice", FullName: "type-int-slice", FullEnv: "TYPE_INT_SLICE", Type: "int-slice", Value: ([]int)(nil), Pointer: &f.Type.IntSlice},
-		{Name: "duration-slice", FullName: "type-duration-slice", FullEnv: "TYPE_DURATION_SLICE", Type: "time.duration-slice", Value: ([]time.Duration)(nil), Pointer: &f.Type.DurationSlice},
-		{Name: "string-slice", FullName: "type-string-slice", FullEnv: "TYPE_STRING_SLICE", Type: "string-slice", Value: ([]string)(nil), Pointer: &f.Type.StringSlice},
-		{Name: "bool-slice", FullName: "type-bool-slice", FullEnv: "TYPE_BOOL_SLICE", Type: "bool-slice", Value: ([]bool)(nil), Pointer: &f.Type.BoolSlice},
-		{Name: "string-to-string", FullName: "type-string-to-string", FullEnv: "TYPE_STRING_TO_STRING", Type: "string-to-string", Value: (map[string]string)(nil), Pointer: &f.Type.StringToString},
-		{Name: "string-to-int", FullName: "type-string-to-int", FullEnv: "TYPE_STRING_TO_INT", Type: "string-to-int", Value: (map[string]int)(nil), Pointer: &f.Type.StringToInt},
+		{Name: "string", FullName: "string", FullEnv: "STRING", EnableEnv: true, Shorthand: "s", Usage: "foo", EnvSplit: ",", Type: "string", Value: "normal", Path: []string{"string"}, Field: "String", Pointer: &f.String},
+		{Name: "array", FullName: "array", FullEnv: "ARRAY", Type: "string-slice", Value: []string{"foo"}, Path: []string{"array"}, Field: "Array", Pointer: &f.Array},
+		{Name: "map", FullName: "map", FullEnv: "MAP", Type: "string-to-string", Value: map[string]string{"foo": "bar"}, Path: []string{"map"}, Field: "Map", Pointer: &f.Map},
+
+		{Name: "new-name", FullName: "new-name", FullEnv: "NEW_NAME", Type: "string", Value: "", Path: []string{"new-name"}, Field: "OverwriteName", Pointer: &f.OverwriteName},
+		{Name: "prefix", FullName: "new-prefix-prefix", FullEnv: "NEW_PREFIX_PREFIX", Type: "string", Value: "", Path: []string{"new-prefix", "prefix"}, Field: "OverwritePrefix.Prefix", Pointer: &f.OverwritePrefix.Prefix},
+
+		{Name: "required", FullName: "required", FullEnv: "REQUIRED", Type: "string", Value: "", Required: true, Path: []string{"required"}, Field: "Required", Pointer: &f.Required},
+
+		{Name: "short", FullName: "short", FullEnv: "SHORT", Type: "string", Value: "", Path: []string{"short"}, Field: "Short", Pointer: &f.Short},
+		{Name: "short-env", FullName: "short-env", FullEnv: "SHORT_ENV", EnableEnv: true, Type: "string", Value: "", Path: []string{"short-env"}, Field: "ShortEnv", Pointer: &f.ShortEnv},
+		{Name: "short-flat", FullName: "short-flat", FullEnv: "SHORT_FLAT", Type: "string", Value: "", Path: []string{"short-flat"}, Field: "ShortFlat", Pointer: &f.ShortFlat},
+		{Name: "short-usage", FullName: "short-usage", FullEnv: "SHORT_USAGE", Type: "string", Value: "", Usage: "foo", Path: []string{"short-usage"}, Field: "ShortUsage", Pointer: &f.ShortUsage},
+
+		{Name: "inline", FullName: "inline", FullEnv: "INLINE", Type: "string", Value: "", Path: []string{"inline"}, Field: "TestInline.Inline", Pointer: &f.TestInline.Inline},
+
+		{Name: "flat", FullName: "flat", FullEnv: "FLAT", Type: "string", Value: "", Path: []string{"flat"}, Field: "Flat.Flat", Pointer: &f.Flat.Flat},
+
+		{Name: "flat2", FullName: "flat2", FullEnv: "FLAT2", Type: "string", Value: "", Path: []string{"flat2"}, Field: "Flat2.Flat2", Pointer: &f.Flat2.Flat2},
+
+		{Name: "int", FullName: "type-int", FullEnv: "TYPE_INT", Type: "int", Value: 0, Path: []string{"type", "int"}, Field: "Type.Int", Pointer: &f.Type.Int},
+		{Name: "int32", FullName: "type-int32", FullEnv: "TYPE_INT32", Type: "int32", Value: int32(0), Path: []string{"type", "int32"}, Field: "Type.Int32", Pointer: &f.Type.Int32},
+		{Name: "int64", FullName: "type-int64", FullEnv: "TYPE_INT64", Type: "int64", Value: int64(0), Path: []string{"type", "int64"}, Field: "Type.Int64", Pointer: &f.Type.Int64},
+		{Name: "duration", FullName: "type-duration", FullEnv: "TYPE_DURATION", Type: "time.duration", Value: time.Duration(0), Path: []string{"type", "duration"}, Field: "Type.Duration", Pointer: &f.Type.Duration},
+		{Name: "string", FullName: "type-string", FullEnv: "TYPE_STRING", Type: "string", Value: "", Path: []string{"type", "string"}, Field: "Type.String", Pointer: &f.Type.String},
+		{Name: "bool", FullName: "type-bool", FullEnv: "TYPE_BOOL", Type: "bool", Value: false, Path: []string{"type", "bool"}, Field: "Type.Bool", Pointer: &f.Type.Bool},
+		{Name: "int-slice", FullName: "type-int-slice", FullEnv: "TYPE_INT_SLICE", Type: "int-slice", Value: ([]int)(nil), Path: []string{"type", "int-slice"}, Field: "Type.IntSlice", Pointer: &f.Type.IntSlice},
+		{Name: "duration-slice", FullName: "type-duration-slice", FullEnv: "TYPE_DURATION_SLICE", Type: "time.duration-slice", Value: ([]time.Duration)(nil), Path: []string{"type", "duration-slice"}, Field: "Type.DurationSlice", Pointer: &f.Type.DurationSlice},
+		{Name: "string-slice", FullName: "type-string-slice", FullEnv: "TYPE_STRING_SLICE", Type: "string-slice", Value: ([]string)(nil), Path: []string{"type", "string-slice"}, Field: "Type.StringSlice", Pointer: &f.Type.StringSlice},
+		{Name: "bool-slice", FullName: "type-bool-slice", FullEnv: "TYPE_BOOL_SLICE", Type: "bool-slice", Value: ([]bool)(nil), Path: []string{"type", "bool-slice"}, Field: "Type.BoolSlice", Pointer: &f.Type.BoolSlice},
+		{Name: "string-to-string", FullName: "type-string-to-string", FullEnv: "TYPE_STRING_TO_STRING", Type: "string-to-string", Value: (map[string]string)(nil), Path: []string{"type", "string-to-string"}, Field: "Type.StringToString", Pointer: &f.Type.StringToString},
+		{Name: "string-to-int", FullName: "type-string-to-int", FullEnv: "TYPE_STRING_TO_INT", Type: "string-to-int", Value: (map[string]int)(nil), Path: []string{"type", "string-to-int"}, Field: "Type.StringToInt", Pointer: &f.Type.StringToInt},
 	}, result)
 
 	// Can be registered
@@ -277,6 +284,69 @@ func Test_resolveCobraType(t *testing.T) {
 			}{},
 			expectedType: "string-to-int",
 		},
+		{
+			name: "uint64",
+			field: struct {
+				M uint64
+			}{},
+			expectedType: "uint64",
+		},
+		{
+			name: "float64-slice",
+			field: struct {
+				M []float64
+			}{},
+			expectedType: "float64-slice",
+		},
+		{
+			name: "bytes",
+			field: struct {
+				M []byte
+			}{},
+			expectedType: "bytes-base64",
+		},
+		{
+			name: "net.IP",
+			field: struct {
+				M net.IP
+			}{},
+			expectedType: "net.ip",
+		},
+		{
+			name: "map string int64",
+			field: struct {
+				M map[string]int64
+			}{},
+			expectedType: "string-to-int64",
+		},
+		{
+			name: "named type",
+			field: struct {
+				M testNamedSlice
+			}{},
+			expectedType: "string-slice",
+		},
+		{
+			name: "pointer",
+			field: struct {
+				M *time.Duration
+			}{},
+			expectedType: "time.duration",
+		},
+		{
+			name: "pflag.Value",
+			field: struct {
+				M testValue
+			}{},
+			expectedType: "pflag.value",
+		},
+		{
+			name: "encoding.TextUnmarshaler",
+			field: struct {
+				M zapcore.Level
+			}{},
+			expectedType: "encoding.text",
+		},
 		{
 			name: "invalid: complex type map",
 			field: struct {
@@ -313,6 +383,7 @@ func TestResolveFlagVariableWithInvalid(t *testing.T) {
 	err = ResolveFlagVariable(nil, &n)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not supported flag type: int-to-string")
+	assert.Equal(t, "m", err.(*errorw.Error).Fields["name"])
 }
 
 func TestResolveFlagVariableWithEnv(t *testing.T) {
@@ -352,6 +423,71 @@ func TestResolveFlagVariableWithEnv(t *testing.T) {
 	assert.Equal(t, []int{3, 4}, m.Slice3)
 }
 
+func TestResolveFlagVariableWithEnvName(t *testing.T) {
+	// Monkey patch
+	monkey.Patch(os.Getenv, func(key string) string {
+		switch key {
+		case "FOO_NAME":
+			return "prefix"
+		case "BAR_NAME":
+			return "option"
+		case "CUSTOM":
+			return "custom"
+		case "OLD_ALIAS":
+			return "alias"
+		case "FOO_FUNC_NAME":
+			return "func"
+		}
+		return ""
+	})
+	defer monkey.Unpatch(os.Getenv)
+
+	metadata.SetAppName("foo")
+	defer metadata.SetAppName("")
+
+	type testEnvFlag struct {
+		Name   string `flag:"env"`
+		Custom string `flag:"env=CUSTOM"`
+		Alias  string `flag:"env env-alias=OLD_ALIAS"`
+	}
+
+	// Prefix by app name
+	logger, logs := newObservedLogger()
+	log.SetBgLogger(logger)
+	var m testEnvFlag
+	cmd := cobra.Command{}
+	err := ResolveFlagVariable(&cmd, &m)
+	require.NoError(t, err)
+	assert.Equal(t, testEnvFlag{Name: "prefix", Custom: "custom", Alias: "alias"}, m)
+	assert.Contains(t, cmd.Flag("name").Usage, "FOO_NAME")
+
+	require.Len(t, logs.All(), 1)
+	assert.Equal(t, map[string]interface{}{"env": "OLD_ALIAS", "replacement": "FOO_ALIAS"}, logs.All()[0].ContextMap())
+
+	// Prefix by option
+	m = testEnvFlag{}
+	err = ResolveFlagVariable(&cobra.Command{}, &m, WithEnvPrefix("BAR"))
+	require.NoError(t, err)
+	assert.Equal(t, "option", m.Name)
+
+	// Name function
+	var m2 struct {
+		Name string `flag:"env"`
+	}
+	err = ResolveFlagVariable(&cobra.Command{}, &m2, WithEnvNameFunc(func(fullName string) string {
+		return "FUNC_" + genEnv(fullName)
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "func", m2.Name)
+
+	// Without prefix
+	m = testEnvFlag{}
+	err = ResolveFlagVariable(&cobra.Command{}, &m, WithEnvPrefix(""))
+	require.NoError(t, err)
+	assert.Equal(t, "", m.Name)
+	assert.Equal(t, "custom", m.Custom)
+}
+
 func TestResolveFlagVariableWithEnvAndUsage(t *testing.T) {
 	// Monkey patch
 	monkey.Patch(os.Getenv, func(key string) string {
@@ -408,6 +544,219 @@ func TestResolveFlagVariableWithConflictedName(t *testing.T) {
 	assert.Contains(t, err.Error(), "duplicated flag full name")
 }
 
+type TestCommon struct {
+	Verbose bool `flag:"env"`
+	Server  struct {
+		Port int `flag:""`
+	} `flag:""`
+}
+
+func TestResolveFlagVariableWithEmbedded(t *testing.T) {
+	m := struct {
+		TestCommon `flag:""`
+		Name       string `flag:""`
+	}{}
+
+	cmd := cobra.Command{}
+	require.NoError(t, ResolveFlagVariable(&cmd, &m, WithEnvPrefix("")))
+	assert.NotNil(t, cmd.Flag("verbose"))
+	assert.NotNil(t, cmd.Flag("server-port"))
+	assert.Contains(t, cmd.Flag("verbose").Usage, "VERBOSE")
+	assert.Equal(t, []string{"server", "port"}, findBinding(&m).flags[1].Path)
+
+	// Keep type name as prefix
+	m2 := struct {
+		TestCommon `flag:""`
+	}{}
+	cmd = cobra.Command{}
+	require.NoError(t, ResolveFlagVariable(&cmd, &m2, WithEmbeddedPrefix()))
+	assert.NotNil(t, cmd.Flag("test-common-verbose"))
+
+	m3 := struct {
+		TestCommon `flag:"name=common"`
+	}{}
+	cmd = cobra.Command{}
+	require.NoError(t, ResolveFlagVariable(&cmd, &m3))
+	assert.NotNil(t, cmd.Flag("common-server-port"))
+}
+
+func TestResolveFlagVariableWithConflictedEmbedded(t *testing.T) {
+	m := struct {
+		TestCommon `flag:""`
+		Verbose    bool `flag:""`
+	}{}
+
+	err := ResolveFlagVariable(&cobra.Command{}, &m)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicated flag full name: verbose")
+	assert.Equal(t, map[string]interface{}{
+		"name":     "verbose",
+		"field":    "TestCommon.Verbose",
+		"conflict": "Verbose",
+	}, err.(*errorw.Error).Fields)
+}
+
+func TestResolveFlagVariableWithUnsupportedKind(t *testing.T) {
+	testCases := []struct {
+		name     string
+		f        interface{}
+		expected string
+	}{
+		{
+			name: "interface",
+			f: &struct {
+				Value interface{} `flag:""`
+			}{},
+			expected: "interface field cannot be a flag",
+		},
+		{
+			name: "func",
+			f: &struct {
+				Fn func() `flag:""`
+			}{},
+			expected: "func field cannot be a flag",
+		},
+		{
+			name: "chan",
+			f: &struct {
+				Nested struct {
+					Ch chan int `flag:""`
+				} `flag:""`
+			}{},
+			expected: "chan field cannot be a flag",
+		},
+		{
+			name: "pointer to struct",
+			f: &struct {
+				In *struct {
+					Name string `flag:""`
+				} `flag:""`
+			}{},
+			expected: "pointer to struct field cannot be a flag",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ResolveFlagVariable(&cobra.Command{}, tc.f)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expected)
+		})
+	}
+
+	// Field path is reported
+	err := ResolveFlagVariable(&cobra.Command{}, testCases[2].f)
+	assert.Equal(t, "Nested.Ch", err.(*errorw.Error).Fields["field"])
+
+	err = ResolveFlagVariable(&cobra.Command{}, testCases[3].f)
+	assert.Equal(t, map[string]interface{}{"name": "in", "field": "In", "kind": "struct"}, err.(*errorw.Error).Fields)
+}
+
+func TestResolveFlagVariableWithInvalidTag(t *testing.T) {
+	testCases := []struct {
+		name          string
+		f             interface{}
+		expected      string
+		expectedField string
+	}{
+		{
+			name: "unknown key",
+			f: &struct {
+				Name string `flag:"requird"`
+			}{},
+			expected:      "invalid flag tag: unknown flag tag key: requird",
+			expectedField: "Name",
+		},
+		{
+			name: "nested",
+			f: &struct {
+				Nested struct {
+					Value string `flag:"env=maybe"`
+				} `flag:""`
+			}{},
+			expected:      "invalid value of flag tag env, require a bool or an env name: maybe",
+			expectedField: "Nested.Value",
+		},
+		{
+			name: "collection",
+			f: &struct {
+				Upstreams []struct {
+					URL string `flag:"usage='foo"`
+				} `flag:"items=2"`
+			}{},
+			expected:      "unterminated quote",
+			expectedField: "Upstreams[].URL",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			err := ResolveFlagVariable(cmd, tc.f)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expected)
+			assert.Equal(t, tc.expectedField, err.(*errorw.Error).Fields["field"])
+			assert.False(t, cmd.PersistentFlags().HasFlags())
+		})
+	}
+}
+
+func TestResolveFlagVariableWithAlias(t *testing.T) {
+	// Monkey patch
+	monkey.Patch(os.Getenv, func(key string) string {
+		switch key {
+		case "SERVER_OLD_PORT":
+			return "8080"
+		}
+		return ""
+	})
+	defer monkey.Unpatch(os.Getenv)
+
+	type testAliasFlag struct {
+		Server struct {
+			Port int `flag:"env alias=old-port|legacy-port"`
+		} `flag:""`
+		Name    string `flag:"required alias=old-name"`
+		Hidden  string `flag:"hidden"`
+		Retired string `flag:"deprecated='use --name instead' alias=older-retired"`
+	}
+
+	logger, logs := newObservedLogger()
+	log.SetBgLogger(logger)
+	var m testAliasFlag
+	cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+	require.NoError(t, ResolveFlagVariable(&cmd, &m, WithEnvPrefix("")))
+	assert.Equal(t, 8080, m.Server.Port)
+	require.Len(t, logs.All(), 1)
+	assert.Equal(t, map[string]interface{}{"env": "SERVER_OLD_PORT", "replacement": "SERVER_PORT"}, logs.All()[0].ContextMap())
+
+	assert.True(t, cmd.Flag("server-old-port").Hidden)
+	assert.True(t, cmd.Flag("server-legacy-port").Hidden)
+	assert.True(t, cmd.Flag("hidden").Hidden)
+	assert.Equal(t, "use --name instead", cmd.Flag("retired").Deprecated)
+	// Old names point to the new name, even if it is deprecated
+	assert.Equal(t, "use --server-port instead", cmd.Flag("server-old-port").Deprecated)
+	assert.Equal(t, "use --retired instead", cmd.Flag("older-retired").Deprecated)
+
+	// Old name satisfies required and prints a deprecation message
+	_, output, err := executeCommandC(&cmd, "--old-name=foo", "--server-legacy-port=9090")
+	require.NoError(t, err)
+	assert.Equal(t, "foo", m.Name)
+	assert.Equal(t, 9090, m.Server.Port)
+	assert.Equal(t, SourceFlag, Source(&m.Name))
+	assert.Contains(t, output, "Flag --old-name has been deprecated, use --name instead\n")
+	assert.Contains(t, output, "Flag --server-legacy-port has been deprecated, use --server-port instead\n")
+	assert.Len(t, logs.All(), 1)
+
+	// Old names are checked for conflicts
+	err = ResolveFlagVariable(&cobra.Command{}, &struct {
+		Name string `flag:""`
+		New  string `flag:"alias=name"`
+	}{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicated flag full name: name")
+}
+
 func Test_resolveFlagsDepth(t *testing.T) {
 	FlagMaxDepth = 2
 	defer func() {
@@ -480,15 +829,166 @@ func TestResolveFlagVariableWithWrongEnvSplit(t *testing.T) {
 }
 
 func TestResolveFlagVariableWithRequired(t *testing.T) {
+	path, cleanup := writeTempFile(t, "config.yaml", "config: config\n")
+	defer cleanup()
+
+	// Monkey patch
+	monkey.Patch(os.Getenv, func(key string) string {
+		switch key {
+		case "ENV":
+			return "env"
+		}
+		return ""
+	})
+	defer monkey.Unpatch(os.Getenv)
+
 	m := struct {
-		Name string `flag:"required"`
+		ConfigFile string `flag:"config-file"`
+		Flag       string `flag:"required"`
+		Env        string `flag:"required env"`
+		Config     string `flag:"required env"`
 	}{}
 
-	cmd := cobra.Command{}
+	cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
 	err := ResolveFlagVariable(&cmd, &m)
 	assert.NoError(t, err)
 
-	cmd.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
-		assert.Equal(t, []string{"true"}, flag.Annotations[cobra.BashCompOneRequiredFlag])
-	})
+	// Not set
+	_, _, err = executeCommandC(&cmd)
+	require.Error(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"flag":   "required flag --flag is not set",
+		"config": "required flag --config or env CONFIG is not set",
+	}, err.(*errorw.Error).Fields)
+
+	// Set by flag parameter, env and config file
+	_, _, err = executeCommandC(&cmd, "--flag=flag", "--config-file", path)
+	assert.NoError(t, err)
+	assert.Equal(t, "flag", m.Flag)
+	assert.Equal(t, "env", m.Env)
+	assert.Equal(t, "config", m.Config)
+}
+
+func TestResolveFlagVariableWithRequiredInSubCommand(t *testing.T) {
+	m := struct {
+		Flag string `flag:"required"`
+	}{}
+
+	var persistentPreRun bool
+	root := &cobra.Command{Use: "root", Run: func(*cobra.Command, []string) {}}
+	sub := &cobra.Command{
+		Use:              "sub",
+		PersistentPreRun: func(*cobra.Command, []string) { persistentPreRun = true },
+		Run:              func(*cobra.Command, []string) {},
+	}
+	root.AddCommand(sub)
+	require.NoError(t, ResolveFlagVariable(root, &m))
+
+	// Checked before the persistent pre-run of sub command
+	_, _, err := executeCommandC(root, "sub")
+	require.Error(t, err)
+	assert.Equal(t, map[string]interface{}{"flag": "required flag --flag is not set"}, err.(*errorw.Error).Fields)
+	assert.False(t, persistentPreRun)
+
+	_, _, err = executeCommandC(root, "sub", "--flag=foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", m.Flag)
+	assert.True(t, persistentPreRun)
+}
+
+func TestResolveFlagVariableWithRecreatedCommands(t *testing.T) {
+	var m struct {
+		Flag string `flag:"required"`
+	}
+	sub := &cobra.Command{
+		Use:              "sub",
+		PersistentPreRun: func(*cobra.Command, []string) {},
+		Run:              func(*cobra.Command, []string) {},
+	}
+	old := &cobra.Command{Use: "root"}
+	old.AddCommand(sub)
+	require.NoError(t, ResolveFlagVariable(old, &m))
+
+	// Hooks of the previous root are not chained to its sub command added to another root
+	root := &cobra.Command{Use: "root"}
+	root.AddCommand(sub)
+	_, _, err := executeCommandC(root, "sub")
+	assert.NoError(t, err)
+
+	// Hooks are removed with the last binding of the command
+	require.NoError(t, ResolveFlagVariable(root, &m))
+	persistentHooks.Lock()
+	for _, h := range persistentHooks.list {
+		assert.NotSame(t, old, h.cmd)
+	}
+	persistentHooks.Unlock()
+	_, _, err = executeCommandC(root, "sub")
+	assert.Error(t, err)
+}
+
+func TestResolveLocalFlagVariable(t *testing.T) {
+	var rootFlag struct {
+		Root string `flag:""`
+	}
+	var subFlag struct {
+		Sub string `flag:"required"`
+	}
+
+	var preRun bool
+	root := &cobra.Command{Use: "root", Run: func(*cobra.Command, []string) {}}
+	sub := &cobra.Command{
+		Use:    "sub",
+		PreRun: func(*cobra.Command, []string) { preRun = true },
+		Run:    func(*cobra.Command, []string) {},
+	}
+	grandchild := &cobra.Command{Use: "grandchild", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(sub)
+	sub.AddCommand(grandchild)
+
+	require.NoError(t, ResolveFlagVariable(root, &rootFlag))
+	require.NoError(t, ResolveLocalFlagVariable(sub, &subFlag))
+
+	assert.NotNil(t, sub.LocalFlags().Lookup("sub"))
+	assert.False(t, sub.HasAvailablePersistentFlags())
+
+	// Local flags are not inherited
+	_, _, err := executeCommandC(root, "sub", "grandchild", "--sub=foo")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown flag: --sub")
+
+	// Local flags are not validated by other commands
+	_, _, err = executeCommandC(root, "sub", "grandchild", "--root=foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", rootFlag.Root)
+
+	// Validate in PreRunE
+	_, _, err = executeCommandC(root, "sub")
+	require.Error(t, err)
+	assert.Equal(t, map[string]interface{}{"sub": "required flag --sub is not set"}, err.(*errorw.Error).Fields)
+	assert.False(t, preRun)
+
+	_, _, err = executeCommandC(root, "sub", "--sub=foo", "--root=bar")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", subFlag.Sub)
+	assert.Equal(t, "bar", rootFlag.Root)
+	assert.True(t, preRun)
+}
+
+func TestResolveFlagVariableWithRegisteredFlag(t *testing.T) {
+	var foo struct {
+		Name string `flag:""`
+	}
+	var bar struct {
+		Number int    `flag:""`
+		Name   string `flag:""`
+	}
+
+	cmd := &cobra.Command{Run: func(*cobra.Command, []string) {}}
+	require.NoError(t, ResolveLocalFlagVariable(cmd, &foo))
+
+	err := ResolveLocalFlagVariable(cmd, &bar)
+	require.Error(t, err)
+	assert.Equal(t, "flag is already registered: name", err.(*errorw.Error).Err.Error())
+	// Nothing is registered
+	assert.Nil(t, cmd.Flags().Lookup("number"))
 }
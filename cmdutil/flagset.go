@@ -0,0 +1,225 @@
+package cmdutil
+
+import (
+	goflag "flag"
+	"reflect"
+
+	"github.com/spf13/pflag"
+
+	"github.com/XSAM/go-hybrid/errorw"
+)
+
+// ResolveFlagSetVariable register flags and env via tags in struct to a pflag.FlagSet, without cobra.
+// Call LoadFlagVariable after the flag set is parsed, to load the config file and validate values.
+//
+//	fs := pflag.NewFlagSet("app", pflag.ExitOnError)
+//	err := cmdutil.ResolveFlagSetVariable(fs, &flag)
+//	fs.Parse(os.Args[1:])
+//	err = cmdutil.LoadFlagVariable(&flag)
+func ResolveFlagSetVariable(fs *pflag.FlagSet, f interface{}, opts ...Option) error {
+	b, pflags, err := resolveBinding(f, newOptions(opts))
+	if err != nil {
+		return err
+	}
+	err = b.register(fs, pflags)
+	if err != nil {
+		return err
+	}
+
+	addBinding(b)
+	return nil
+}
+
+// ResolveGoFlagSetVariable register flags and env via tags in struct to a flag.FlagSet of the standard library.
+// Shorthands are not supported. Call LoadFlagVariable after the flag set is parsed.
+func ResolveGoFlagSetVariable(fs *goflag.FlagSet, f interface{}, opts ...Option) error {
+	b, pflags, err := resolveBinding(f, newOptions(opts))
+	if err != nil {
+		return err
+	}
+	for _, pf := range pflags {
+		if fs.Lookup(pf.Name) != nil {
+			return errorw.NewMessagef("flag is already registered: %s", pf.Name).WithField("name", pf.Name)
+		}
+	}
+	// Flags are registered to a pflag.FlagSet, which shares values with fs
+	err = b.register(pflag.NewFlagSet(fs.Name(), pflag.ContinueOnError), pflags)
+	if err != nil {
+		return err
+	}
+	for _, pf := range pflags {
+		fs.Var(pf.Value, pf.Name, pf.Usage)
+	}
+	b.goFlagSet = fs
+
+	addBinding(b)
+	return nil
+}
+
+// LoadFlagVariable load the config file, then validate values of the struct resolved by
+// ResolveFlagSetVariable or ResolveGoFlagSetVariable. It should be called after the flag set is parsed.
+// Commands of ResolveFlagVariable load values before running, so there is no need to call it.
+func LoadFlagVariable(f interface{}) error {
+	b := findBinding(f)
+	if b == nil {
+		return errorw.NewMessage("struct is not resolved by ResolveFlagVariable")
+	}
+	return b.load()
+}
+
+// resolveBinding resolve flags of struct, and create flags which are registered by register.
+func resolveBinding(f interface{}, o options) (*binding, []*pflag.Flag, error) {
+	t := reflect.TypeOf(f)
+	if t.Kind() != reflect.Ptr {
+		return nil, nil, errorw.NewMessage("flag variable require pointer type")
+	}
+
+	err := checkTags(t, "", 1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defaults := copyStruct(f)
+	var flags flags
+	var collections []*collection
+	flags = resolver{mode: slotGrow, options: &o, collections: &collections}.resolve(f, flags, "", nil, "", nil, 1)
+	for i, v := range flags {
+		if v.EnvName != "" {
+			flags[i].FullEnv = v.EnvName
+		} else {
+			flags[i].FullEnv = o.env(v.FullName)
+			// Env of old names are deprecated too
+			for _, alias := range v.Aliases {
+				flags[i].EnvAliases = append(flags[i].EnvAliases, o.env(alias))
+			}
+		}
+	}
+
+	// Check full name conflict, e.g. fields promoted from embedded structs
+	set := make(map[string]string)
+	for _, v := range flags {
+		for _, name := range append([]string{v.FullName}, v.Aliases...) {
+			if field, ok := set[name]; ok {
+				return nil, nil, errorw.NewMessagef("duplicated flag full name: %s", name).
+					WithField("name", name).
+					WithField("field", field).
+					WithField("conflict", v.Field)
+			} else {
+				set[name] = v.Field
+			}
+		}
+	}
+
+	// Check fields which cannot be flags
+	for _, v := range flags {
+		err := v.checkKind()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Check config file flag
+	var configFile bool
+	for _, v := range flags {
+		if !v.ConfigFile {
+			continue
+		}
+		if configFile {
+			return nil, nil, errorw.NewMessagef("duplicated config file flag: %s", v.FullName)
+		}
+		if v.Type != "string" {
+			return nil, nil, errorw.NewMessagef("config file flag require string type: %s", v.FullName)
+		}
+		configFile = true
+	}
+
+	// Create flags before registering, so nothing is registered if any flag is invalid
+	pflags := make([]*pflag.Flag, 0, len(flags))
+	for _, v := range flags {
+		f, err := newFlag(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		pflags = append(pflags, f)
+
+		aliases, err := newAliasFlags(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		pflags = append(pflags, aliases...)
+	}
+	validators, err := newValidators(flags)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, v := range flags {
+		_, err := v.completion()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	b := newBinding(nil, flags)
+	b.target = f
+	b.defaults = defaults
+	b.options = o
+	b.validators = validators
+	b.collections = collections
+	return b, pflags, nil
+}
+
+// register add flags to the flag set, then set values of env.
+// Nothing is registered if any flag is already registered.
+func (b *binding) register(flagSet *pflag.FlagSet, pflags []*pflag.Flag) error {
+	for _, f := range pflags {
+		if flagSet.Lookup(f.Name) != nil {
+			return errorw.NewMessagef("flag is already registered: %s", f.Name).WithField("name", f.Name)
+		}
+	}
+	b.flagSet = flagSet
+	for _, f := range pflags {
+		b.flagSet.AddFlag(f)
+	}
+
+	// Register env
+	err := b.registerEnv()
+	if err != nil {
+		return errorw.Wrap(err, "register env value")
+	}
+	return nil
+}
+
+// load load values, then validate them.
+func (b *binding) load() error {
+	err := b.loadValues()
+	if err != nil {
+		return err
+	}
+	return b.validate()
+}
+
+// loadValues replace items of slices set by env with flag parameters, check old names,
+// load the config file, then set items of slices and maps.
+func (b *binding) loadValues() error {
+	// Flags of the standard library are not marked as changed by pflag
+	if b.goFlagSet != nil {
+		b.goFlagSet.Visit(func(gf *goflag.Flag) {
+			if pf := b.flagSet.Lookup(gf.Name); pf != nil {
+				pf.Changed = true
+			}
+		})
+	}
+
+	err := b.replaceEnvItems()
+	if err != nil {
+		return err
+	}
+	b.checkAliases()
+	if b.configFile() != nil {
+		err = b.loadConfigFile(b.configFilePath())
+		if err != nil {
+			return err
+		}
+	}
+	return b.applyCollections()
+}
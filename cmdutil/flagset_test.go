@@ -0,0 +1,131 @@
+package cmdutil
+
+import (
+	goflag "flag"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/XSAM/go-hybrid/log"
+)
+
+type testFlagSetFlag struct {
+	ConfigFile string `flag:"config-file"`
+	Name       string `flag:"env required"`
+	Number     int    `flag:"env min=1"`
+	Enable     bool   `flag:""`
+	Nested     struct {
+		Value string `flag:""`
+	} `flag:""`
+}
+
+func TestResolveFlagSetVariable(t *testing.T) {
+	monkey.Patch(os.Getenv, func(key string) string {
+		if key == "NUMBER" {
+			return "42"
+		}
+		return ""
+	})
+	defer monkey.Unpatch(os.Getenv)
+
+	path, cleanup := writeTempFile(t, "config.yaml", "nested:\n  value: bar\n")
+	defer cleanup()
+
+	var f testFlagSetFlag
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	err := ResolveFlagSetVariable(fs, &f)
+	require.NoError(t, err)
+	assert.Equal(t, 42, f.Number)
+
+	err = fs.Parse([]string{"--name=foo", "--enable", "--config-file=" + path})
+	require.NoError(t, err)
+	err = LoadFlagVariable(&f)
+	require.NoError(t, err)
+
+	assert.Equal(t, "foo", f.Name)
+	assert.True(t, f.Enable)
+	assert.Equal(t, "bar", f.Nested.Value)
+	assert.Equal(t, SourceFlag, Source(&f.Name))
+	assert.Equal(t, SourceEnv, Source(&f.Number))
+	assert.Equal(t, SourceConfig, Source(&f.Nested.Value))
+
+	// Required
+	var g testFlagSetFlag
+	fs = pflag.NewFlagSet("test", pflag.ContinueOnError)
+	err = ResolveFlagSetVariable(fs, &g)
+	require.NoError(t, err)
+	err = LoadFlagVariable(&g)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "required flag --name or env NAME is not set")
+
+	// Already registered
+	fs = pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.String("name", "", "")
+	err = ResolveFlagSetVariable(fs, &testFlagSetFlag{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "flag is already registered: name")
+	assert.Nil(t, fs.Lookup("number"))
+}
+
+func TestResolveGoFlagSetVariable(t *testing.T) {
+	var f testFlagSetFlag
+	fs := goflag.NewFlagSet("test", goflag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	err := ResolveGoFlagSetVariable(fs, &f)
+	require.NoError(t, err)
+
+	err = fs.Parse([]string{"-name=foo", "-number=2", "-enable", "-nested-value=bar"})
+	require.NoError(t, err)
+	err = LoadFlagVariable(&f)
+	require.NoError(t, err)
+
+	assert.Equal(t, "foo", f.Name)
+	assert.Equal(t, 2, f.Number)
+	assert.True(t, f.Enable)
+	assert.Equal(t, "bar", f.Nested.Value)
+	assert.Equal(t, SourceFlag, Source(&f.Name))
+	assert.Equal(t, SourceDefault, Source(&f.ConfigFile))
+
+	// Validation
+	var g testFlagSetFlag
+	fs = goflag.NewFlagSet("test", goflag.ContinueOnError)
+	err = ResolveGoFlagSetVariable(fs, &g)
+	require.NoError(t, err)
+	err = fs.Parse([]string{"-name=foo", "-number=0"})
+	require.NoError(t, err)
+	err = LoadFlagVariable(&g)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "number")
+
+	// Already registered
+	fs = goflag.NewFlagSet("test", goflag.ContinueOnError)
+	fs.String("name", "", "")
+	err = ResolveGoFlagSetVariable(fs, &testFlagSetFlag{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "flag is already registered: name")
+
+	// Old names are logged, since the standard library doesn't print deprecation messages
+	logger, logs := newObservedLogger()
+	log.SetBgLogger(logger)
+	var h struct {
+		Port int `flag:"alias=old-port"`
+	}
+	fs = goflag.NewFlagSet("test", goflag.ContinueOnError)
+	require.NoError(t, ResolveGoFlagSetVariable(fs, &h))
+	require.NoError(t, fs.Parse([]string{"-old-port=1"}))
+	require.NoError(t, LoadFlagVariable(&h))
+	assert.Equal(t, 1, h.Port)
+	require.Len(t, logs.All(), 1)
+	assert.Equal(t, map[string]interface{}{"flag": "old-port", "replacement": "port"}, logs.All()[0].ContextMap())
+}
+
+func TestLoadFlagVariableWithoutResolving(t *testing.T) {
+	err := LoadFlagVariable(&testFlagSetFlag{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "struct is not resolved by ResolveFlagVariable")
+}
@@ -0,0 +1,69 @@
+package cmdutil
+
+import (
+	"strings"
+
+	"github.com/XSAM/go-hybrid/metadata"
+)
+
+// Option configure how flags of a struct are resolved.
+type Option func(*options)
+
+type options struct {
+	// nil means using the app name
+	envPrefix *string
+	envName   func(fullName string) string
+	// Use type names of embedded structs as prefix
+	embeddedPrefix bool
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		envName: genEnv,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithEnvPrefix set the prefix of env names, which is the upper-cased app name by default.
+// e.g. prefix FOO generate env FOO_NUMBER for flag number. Empty prefix disables prefix.
+func WithEnvPrefix(prefix string) Option {
+	return func(o *options) {
+		o.envPrefix = &prefix
+	}
+}
+
+// WithEnvNameFunc set the function which generate env name from the full name of flag.
+// The default function replace "-" to "_", and upper all character.
+// The env prefix is still added to the generated name.
+func WithEnvNameFunc(fn func(fullName string) string) Option {
+	return func(o *options) {
+		o.envName = fn
+	}
+}
+
+// WithEmbeddedPrefix use the type names of embedded structs as the prefix of their flags, env and config keys.
+// By default, fields of embedded structs are promoted to the parent struct.
+func WithEmbeddedPrefix() Option {
+	return func(o *options) {
+		o.embeddedPrefix = true
+	}
+}
+
+// env return the env name of flag with prefix.
+func (o options) env(fullName string) string {
+	var prefix string
+	if o.envPrefix != nil {
+		prefix = *o.envPrefix
+	} else {
+		prefix = genEnv(metadata.AppName())
+	}
+
+	name := o.envName(fullName)
+	if prefix == "" {
+		return name
+	}
+	return strings.TrimSuffix(prefix, "_") + "_" + name
+}
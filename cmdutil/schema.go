@@ -0,0 +1,277 @@
+package cmdutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/spf13/cobra"
+
+	"github.com/XSAM/go-hybrid/errorw"
+)
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchema is a subset of JSON Schema draft 2020-12.
+type jsonSchema struct {
+	Schema      string      `json:"$schema,omitempty"`
+	Type        string      `json:"type,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	WriteOnly   bool        `json:"writeOnly,omitempty"`
+
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+
+	Enum      []string `json:"enum,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+	Minimum   *float64 `json:"minimum,omitempty"`
+	Maximum   *float64 `json:"maximum,omitempty"`
+	MinLength *int     `json:"minLength,omitempty"`
+	MaxLength *int     `json:"maxLength,omitempty"`
+	MinItems  *int     `json:"minItems,omitempty"`
+	MaxItems  *int     `json:"maxItems,omitempty"`
+}
+
+// JSONSchema return a JSON Schema (draft 2020-12) of the config file of a flag struct.
+// Names, nested sections, types, defaults, usages and validation rules come from the tags.
+// Defaults of a struct resolved by ResolveFlagVariable are the values before loading flags, env and config file.
+func JSONSchema(f interface{}) ([]byte, error) {
+	defaults := structPointer(f)
+	if b := findBinding(f); b != nil {
+		defaults = copyStruct(b.defaults)
+	}
+	flags := resolverOf(f, slotTemplate).resolve(defaults, nil, "", nil, "", nil, 1)
+	validators, err := newValidators(flags)
+	if err != nil {
+		return nil, err
+	}
+	rules := make(map[string]*validator)
+	for _, vd := range validators {
+		rules[vd.flag.FullName] = vd
+	}
+
+	root := &jsonSchema{Schema: jsonSchemaDraft, Type: "object"}
+	for _, v := range flags {
+		if v.ConfigFile || len(v.Path) == 0 {
+			continue
+		}
+
+		// Find or create sections of path
+		section := root
+		for i, key := range v.Path[:len(v.Path)-1] {
+			// Schema of items
+			if v.slotAt(i) != nil {
+				if section.Items != nil {
+					section = section.Items
+				} else {
+					section = section.AdditionalProperties
+				}
+				continue
+			}
+
+			section = section.property(key)
+			if s := v.slotAt(i + 1); s != nil && s.collection.isSlice() && section.Items == nil {
+				section.Type = "array"
+				section.Items = &jsonSchema{Type: "object"}
+			} else if s != nil && !s.collection.isSlice() && section.AdditionalProperties == nil {
+				section.AdditionalProperties = &jsonSchema{Type: "object"}
+			}
+		}
+
+		property, err := newJSONSchema(v, rules[v.FullName])
+		if err != nil {
+			return nil, err
+		}
+		key := v.Path[len(v.Path)-1]
+		*section.property(key) = *property
+		if v.Required {
+			section.Required = append(section.Required, key)
+		}
+	}
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, errorw.Wrap(err, "marshal json schema")
+	}
+	return data, nil
+}
+
+// property return the schema of key in properties, create an object schema if not found.
+func (s *jsonSchema) property(key string) *jsonSchema {
+	if s.Properties == nil {
+		s.Properties = make(map[string]*jsonSchema)
+	}
+	if _, ok := s.Properties[key]; !ok {
+		s.Properties[key] = &jsonSchema{Type: "object"}
+	}
+	return s.Properties[key]
+}
+
+// JSONSchemaCmd return a hidden command which print the JSON Schema of a flag struct.
+func JSONSchemaCmd(f interface{}) *cobra.Command {
+	cmd := cobra.Command{
+		Use:    "json-schema",
+		Short:  "Print JSON Schema of config file",
+		Hidden: true,
+		// Print the schema without a valid config
+		Annotations: map[string]string{skipHookAnnotation: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := JSONSchema(f)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return nil
+		},
+	}
+	return &cmd
+}
+
+// jsonType return the JSON type of flag type.
+func jsonType(flagType string) string {
+	switch flagType {
+	case "bool":
+		return "boolean"
+	case "int", "int8", "int16", "int32", "int64", "count",
+		"uint", "uint8", "uint16", "uint32", "uint64":
+		return "integer"
+	case "float32", "float64":
+		return "number"
+	}
+	return "string"
+}
+
+func newJSONSchema(v flag, vd *validator) (*jsonSchema, error) {
+	schema := jsonSchema{
+		Description: v.Usage,
+		WriteOnly:   v.isSecret(),
+	}
+	// Element schema, which rules of items are applied to
+	item := &schema
+	switch {
+	case v.isList():
+		item = &jsonSchema{Type: jsonType(v.elemType())}
+		schema.Type = "array"
+		schema.Items = item
+	case v.isMap():
+		schema.Type = "object"
+		schema.AdditionalProperties = &jsonSchema{Type: jsonType(v.elemType())}
+	default:
+		schema.Type = jsonType(v.Type)
+	}
+
+	defValue, err := jsonDefault(v)
+	if err != nil {
+		return nil, err
+	}
+	schema.Default = defValue
+
+	if vd == nil {
+		return &schema, nil
+	}
+	rules := v.Rules
+	if item.Type == "string" {
+		item.Enum = rules.OneOf
+		item.Pattern = rules.Regex
+		// Default value of optional flags may not satisfy rules, e.g. empty enum
+		if !validDefault(defValue, rules.OneOf, vd.regex) {
+			schema.Default = nil
+		}
+	}
+
+	bound := func(p *float64) *int {
+		if p == nil {
+			return nil
+		}
+		n := int(*p)
+		return &n
+	}
+	switch {
+	case vd.length && schema.Type == "string":
+		schema.MinLength, schema.MaxLength = bound(vd.min), bound(vd.max)
+		if rules.NonEmpty && schema.MinLength == nil {
+			schema.MinLength = newInt(1)
+		}
+	case vd.length && schema.Type == "array":
+		schema.MinItems, schema.MaxItems = bound(vd.min), bound(vd.max)
+		if rules.NonEmpty && schema.MinItems == nil {
+			schema.MinItems = newInt(1)
+		}
+	case schema.Type == "integer" || schema.Type == "number":
+		schema.Minimum, schema.Maximum = vd.min, vd.max
+	}
+	return &schema, nil
+}
+
+// jsonDefault return the default value of flag in JSON types.
+// Nil pointers and secrets don't have default values.
+func jsonDefault(v flag) (interface{}, error) {
+	value := reflect.ValueOf(v.Pointer).Elem()
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+	if v.isSecret() {
+		return nil, nil
+	}
+
+	// Keep values of JSON types, others are formatted as strings
+	jsonValue := func(flagType string, value reflect.Value) interface{} {
+		if jsonType(flagType) == "string" {
+			return fmt.Sprint(value.Interface())
+		}
+		return value.Interface()
+	}
+	switch {
+	case v.isList():
+		result := make([]interface{}, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			result = append(result, jsonValue(v.elemType(), value.Index(i)))
+		}
+		return result, nil
+	case v.isMap():
+		result := make(map[string]interface{})
+		for _, key := range value.MapKeys() {
+			result[fmt.Sprint(key.Interface())] = jsonValue(v.elemType(), value.MapIndex(key))
+		}
+		return result, nil
+	case jsonType(v.Type) != "string":
+		return value.Interface(), nil
+	}
+	f, err := newFlag(v)
+	if err != nil {
+		return nil, err
+	}
+	return f.DefValue, nil
+}
+
+// validDefault return false if the default value, or any item of it, is not one of enum or doesn't match regex.
+func validDefault(defValue interface{}, enum []string, regex *regexp.Regexp) bool {
+	if defValue == nil {
+		return true
+	}
+	items := []interface{}{defValue}
+	if list, ok := defValue.([]interface{}); ok {
+		items = list
+	}
+	for _, item := range items {
+		s := fmt.Sprint(item)
+		if len(enum) > 0 && !contains(enum, s) {
+			return false
+		}
+		if regex != nil && !regex.MatchString(s) {
+			return false
+		}
+	}
+	return true
+}
+
+func newInt(n int) *int {
+	return &n
+}
@@ -0,0 +1,119 @@
+package cmdutil
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	f := struct {
+		ConfigFile string         `flag:"config-file"`
+		Number     int            `flag:"min=1 max=10" flag-usage:"the number"`
+		Ratio      float64        `flag:""`
+		Name       string         `flag:"required nonempty regex=^[a-z]+$"`
+		Mode       string         `flag:"oneof=dev|prod"`
+		Level      string         `flag:"enum=debug|info"`
+		Tags       []string       `flag:"max=3"`
+		Ports      []int          `flag:""`
+		Labels     map[string]int `flag:""`
+		Timeout    *time.Duration `flag:""`
+		Duration   time.Duration  `flag:""`
+		Password   Secret         `flag:""`
+		Nested     struct {
+			Enable bool `flag:"required"`
+		} `flag:""`
+	}{
+		Number:   1,
+		Mode:     "dev",
+		Ports:    []int{80},
+		Labels:   map[string]int{"a": 1},
+		Duration: time.Second,
+		Password: "password",
+	}
+
+	data, err := JSONSchema(&f)
+	require.NoError(t, err)
+
+	expected := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "duration": {"type": "string", "default": "1s"},
+    "labels": {"type": "object", "default": {"a": 1}, "additionalProperties": {"type": "integer"}},
+    "mode": {"type": "string", "default": "dev", "enum": ["dev", "prod"]},
+    "level": {"type": "string", "enum": ["debug", "info"]},
+    "name": {"type": "string", "pattern": "^[a-z]+$", "minLength": 1},
+    "nested": {
+      "type": "object",
+      "properties": {"enable": {"type": "boolean", "default": false}},
+      "required": ["enable"]
+    },
+    "number": {"type": "integer", "description": "the number", "default": 1, "minimum": 1, "maximum": 10},
+    "password": {"type": "string", "writeOnly": true},
+    "ports": {"type": "array", "default": [80], "items": {"type": "integer"}},
+    "ratio": {"type": "number", "default": 0},
+    "tags": {"type": "array", "default": [], "items": {"type": "string"}, "maxItems": 3},
+    "timeout": {"type": "string"}
+  },
+  "required": ["name"]
+}`
+	assert.JSONEq(t, expected, string(data))
+
+	// Non-pointer value
+	data2, err := JSONSchema(f)
+	require.NoError(t, err)
+	assert.Equal(t, data, data2)
+
+	// Invalid rule
+	_, err = JSONSchema(struct {
+		M int `flag:"min=a"`
+	}{})
+	assert.Error(t, err)
+}
+
+func TestJSONSchemaOfResolvedStruct(t *testing.T) {
+	f := struct {
+		Port int `flag:"env"`
+	}{Port: 8080}
+	require.NoError(t, os.Setenv("SCHEMA_PORT", "80"))
+	defer os.Unsetenv("SCHEMA_PORT")
+
+	cmd := &cobra.Command{Use: "app", Run: func(*cobra.Command, []string) {}}
+	require.NoError(t, ResolveFlagVariable(cmd, &f, WithEnvPrefix("SCHEMA")))
+	_, _, err := executeCommandC(cmd)
+	require.NoError(t, err)
+	require.Equal(t, 80, f.Port)
+
+	// Loaded values are not defaults
+	data, err := JSONSchema(&f)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"default": 8080`)
+}
+
+func TestJSONSchemaCmd(t *testing.T) {
+	f := struct {
+		Number int `flag:""`
+		Port   int `flag:"required"`
+	}{}
+
+	root := &cobra.Command{Use: "app", Run: func(*cobra.Command, []string) {}}
+	cmd := JSONSchemaCmd(&f)
+	root.AddCommand(cmd)
+	require.NoError(t, ResolveFlagVariable(root, &f))
+	assert.True(t, cmd.Hidden)
+
+	// Required flags are not checked
+	_, output, err := executeCommandC(root, "json-schema")
+	require.NoError(t, err)
+	assert.True(t, json.Valid([]byte(output)))
+	assert.Contains(t, output, `"number"`)
+
+	_, _, err = executeCommandC(root)
+	assert.Error(t, err)
+}
@@ -0,0 +1,181 @@
+package cmdutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/XSAM/go-hybrid/errorw"
+)
+
+// Redacted is the output of secret values.
+const Redacted = "***"
+
+// Secret is a string which is redacted when it is printed or marshaled,
+// so it is safe to log a struct contains secrets through zap.Any.
+type Secret string
+
+// String return redacted value, use string(s) to get the actual value.
+func (s Secret) String() string {
+	return Redacted
+}
+
+// MarshalJSON return redacted value.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + Redacted + `"`), nil
+}
+
+var secretType = reflect.TypeOf(Secret(""))
+
+// isSecret return true if flag is tagged with secret or its type is Secret.
+func (v flag) isSecret() bool {
+	return v.Secret || fieldType(v) == secretType
+}
+
+// readSecretFile return the content of file without trailing newlines.
+func readSecretFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errorw.Wrap(err, "read secret file").WithField("path", path)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Dump return the values of a struct resolved by ResolveFlagVariable, keyed by full name of flags.
+// Values of secrets are redacted.
+//
+//	log.BgLogger().Info("flag", zap.Any("flag", cmdutil.Dump(&flag)))
+func Dump(f interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for _, flag := range resolverOf(f, slotCurrent).resolve(structPointer(f), nil, "", nil, "", nil, 1) {
+		value := reflect.ValueOf(flag.Pointer).Elem()
+		switch {
+		case value.Kind() == reflect.Ptr && value.IsNil():
+			result[flag.FullName] = nil
+		case flag.isSecret():
+			result[flag.FullName] = Redacted
+		default:
+			result[flag.FullName] = value.Interface()
+		}
+	}
+	return result
+}
+
+// Redact return a zap object marshaler of the struct which f points to, values of secrets are redacted.
+// Unlike Dump, the output keeps the structure and keys of the struct, like the struct logged by zap.Any.
+//
+//	log.BgLogger().Info("flag", zap.Any("flag", cmdutil.Redact(&flag)))
+func Redact(f interface{}) zapcore.ObjectMarshaler {
+	return redactedStruct{value: reflect.ValueOf(f)}
+}
+
+// redactedStruct marshal exported fields of struct by the keys of encoding/json.
+// Fields which don't contain secrets are marshaled by reflection as they are.
+type redactedStruct struct {
+	value reflect.Value
+}
+
+func (r redactedStruct) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	v := reflect.Indirect(r.value)
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		value := v.Field(i)
+		// Fields of embedded structs are promoted
+		if field.Anonymous && name == "" && reflect.Indirect(value).Kind() == reflect.Struct {
+			err := redactedStruct{value: value}.MarshalLogObject(enc)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		tag, _ := resolveFlagTag(field.Tag)
+		var err error
+		switch {
+		case value.Kind() == reflect.Ptr && value.IsNil():
+			err = enc.AddReflected(name, nil)
+		case tag.enable && tag.secret:
+			enc.AddString(name, Redacted)
+		case !hasSecret(field.Type, 1):
+			err = enc.AddReflected(name, value.Interface())
+		default:
+			err = addRedacted(enc, name, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addRedacted add structs, and slices and maps of structs, which contain secrets.
+func addRedacted(enc zapcore.ObjectEncoder, key string, value reflect.Value) error {
+	value = reflect.Indirect(value)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		return enc.AddArray(key, zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+			for i := 0; i < value.Len(); i++ {
+				err := enc.AppendObject(redactedStruct{value: value.Index(i)})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	case reflect.Map:
+		return enc.AddObject(key, zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			for _, k := range value.MapKeys() {
+				err := enc.AddObject(fmt.Sprint(k.Interface()), redactedStruct{value: value.MapIndex(k)})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	}
+	return enc.AddObject(key, redactedStruct{value: value})
+}
+
+// hasSecret return true if fields of struct type, or its items, are tagged with secret.
+func hasSecret(t reflect.Type, depth int) bool {
+	if depth > FlagMaxDepth {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return hasSecret(t.Elem(), depth)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			if tag, _ := resolveFlagTag(field.Tag); tag.enable && tag.secret {
+				return true
+			}
+			if hasSecret(field.Type, depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package cmdutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSecret(t *testing.T) {
+	s := Secret("password")
+
+	assert.Equal(t, "password", string(s))
+	assert.Equal(t, "***", s.String())
+	assert.Equal(t, "***", fmt.Sprintf("%v", s))
+
+	data, err := json.Marshal(struct {
+		Password Secret
+	}{Password: s})
+	require.NoError(t, err)
+	assert.Equal(t, `{"Password":"***"}`, string(data))
+
+	// Log struct through zap.Any
+	logger, logs := newObservedLogger()
+	logger.Info("flag", zap.Any("flag", struct {
+		Password Secret
+	}{Password: s}))
+	require.Len(t, logs.All(), 1)
+	assert.NotContains(t, fmt.Sprint(logs.All()[0].ContextMap()), "password")
+}
+
+func TestResolveFlagVariableWithSecret(t *testing.T) {
+	path, cleanup := writeTempFile(t, "secret", "password\n\n")
+	defer cleanup()
+
+	// Monkey patch
+	monkey.Patch(os.Getenv, func(key string) string {
+		switch key {
+		case "PASSWORD_FILE", "TOKEN_FILE":
+			return path
+		case "TOKEN":
+			return "token"
+		case "MISSING_FILE":
+			return path + ".missing"
+		}
+		return ""
+	})
+	defer monkey.Unpatch(os.Getenv)
+
+	m := struct {
+		Password Secret `flag:"secret"`
+		// Env has priority over file
+		Token string `flag:"env secret"`
+	}{}
+
+	cmd := cobra.Command{}
+	err := ResolveFlagVariable(&cmd, &m)
+	require.NoError(t, err)
+	assert.Equal(t, Secret("password"), m.Password)
+	assert.Equal(t, "token", m.Token)
+	assert.Equal(t, "[env PASSWORD_FILE]", cmd.Flag("password").Usage)
+	assert.Equal(t, "[env TOKEN, TOKEN_FILE]", cmd.Flag("token").Usage)
+
+	// File not found
+	m2 := struct {
+		Missing string `flag:"secret"`
+	}{}
+	err = ResolveFlagVariable(&cobra.Command{}, &m2)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "read secret file")
+}
+
+func TestDump(t *testing.T) {
+	number := 1
+	m := struct {
+		Number   *int   `flag:""`
+		Pointer  *int   `flag:""`
+		Password Secret `flag:""`
+		Token    string `flag:"secret"`
+		Nested   struct {
+			Name string `flag:""`
+		} `flag:""`
+	}{
+		Number:   &number,
+		Password: "password",
+		Token:    "token",
+	}
+	m.Nested.Name = "foo"
+
+	expected := map[string]interface{}{
+		"number":      &number,
+		"pointer":     nil,
+		"password":    "***",
+		"token":       "***",
+		"nested-name": "foo",
+	}
+	assert.Equal(t, expected, Dump(&m))
+	// Non-pointer value
+	assert.Equal(t, expected, Dump(m))
+}
+
+// Credential is embedded by the struct of TestRedact, which is exported since fields of unexported embedded types are skipped.
+type Credential struct {
+	Key string `flag:"secret"`
+}
+
+func TestRedact(t *testing.T) {
+	number := 1
+	m := struct {
+		Credential
+		Number   *int    `flag:""`
+		Pointer  *string `flag:"secret"`
+		Password Secret  `flag:""`
+		Token    string  `flag:"secret"`
+		Renamed  string  `flag:"secret" json:"renamed"`
+		Ignored  string  `flag:"secret" json:"-"`
+		Ports    []int   `flag:""`
+		Nested   struct {
+			Name  string `flag:""`
+			Token string `flag:"secret"`
+		} `flag:""`
+		Items []struct {
+			Token string `flag:"secret"`
+		} `flag:"items=1"`
+		Backends map[string]struct {
+			Token string `flag:"secret"`
+		} `flag:""`
+		unexported string
+	}{
+		Credential: Credential{Key: "key"},
+		Number:     &number,
+		Password:   "password",
+		Token:      "token",
+		Renamed:    "renamed",
+		Ignored:    "ignored",
+		Ports:      []int{80},
+		unexported: "unexported",
+	}
+	m.Nested.Name = "foo"
+	m.Nested.Token = "token"
+	m.Items = append(m.Items, struct {
+		Token string `flag:"secret"`
+	}{Token: "token"})
+	m.Backends = map[string]struct {
+		Token string `flag:"secret"`
+	}{"a": {Token: "token"}}
+
+	// Log struct through zap.Any
+	buf, err := zapcore.NewJSONEncoder(zapcore.EncoderConfig{}).
+		EncodeEntry(zapcore.Entry{}, []zap.Field{zap.Any("flag", Redact(&m))})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"flag": {
+		"Key": "***",
+		"Number": 1,
+		"Pointer": null,
+		"Password": "***",
+		"Token": "***",
+		"renamed": "***",
+		"Ports": [80],
+		"Nested": {"Name": "foo", "Token": "***"},
+		"Items": [{"Token": "***"}],
+		"Backends": {"a": {"Token": "***"}}
+	}}`, buf.String())
+
+	// Non-pointer value
+	buf2, err := zapcore.NewJSONEncoder(zapcore.EncoderConfig{}).
+		EncodeEntry(zapcore.Entry{}, []zap.Field{zap.Any("flag", Redact(m))})
+	require.NoError(t, err)
+	assert.Equal(t, buf.String(), buf2.String())
+}
+
+func TestSecretInHelp(t *testing.T) {
+	cmd := cobra.Command{Use: "app", Run: func(*cobra.Command, []string) {}}
+	require.NoError(t, ResolveFlagVariable(&cmd, &struct {
+		Password Secret `flag:""`
+		Token    string `flag:"secret"`
+		Empty    string `flag:"secret"`
+	}{Password: "password", Token: "token"}))
+
+	_, output, err := executeCommandC(&cmd, "--help")
+	require.NoError(t, err)
+	assert.Contains(t, output, `(default "***")`)
+	assert.NotContains(t, output, `"password"`)
+	assert.NotContains(t, output, `"token"`)
+}
@@ -2,15 +2,16 @@ package cmdutil
 
 import (
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"unicode"
-)
 
-const (
-	tagKeySeparator = "="
+	"github.com/XSAM/go-hybrid/errorw"
 )
 
+var envNamePattern = regexp.MustCompile("^[A-Z_][A-Z0-9_]*$")
+
 type flagTag struct {
 	// Enable to generate flag
 	enable bool
@@ -24,76 +25,298 @@ type flagTag struct {
 	usage     string
 
 	enableEnv bool
-	envSplit  string
+	// Explicit env name. e.g. env=FOO
+	envName    string
+	envAliases []string
+	envSplit   string
+
+	// Use field value as config file path
+	configFile bool
+	// Number of items of slices of structs
+	items int
+	// Keys of maps of structs
+	keys   []string
+	secret bool
+
+	hidden     bool
+	deprecated string
+	// Old names of flag
+	aliases []string
+
+	// Shell completion of values. e.g. file, dir
+	complete   string
+	extensions []string
+
+	rules rules
+}
+
+// tagKeys are the keys of the flag tag.
+var tagKeys = map[string]bool{
+	"name": true, "type": true, "flat": true, "required": true, "short": true, "usage": true,
+	"env": true, "env-alias": true, "env-split": true,
+	"config-file": true, "items": true, "keys": true, "secret": true,
+	"hidden": true, "deprecated": true, "alias": true, "complete": true, "ext": true,
+	"min": true, "max": true, "oneof": true, "enum": true, "regex": true, "nonempty": true,
+	"requires": true, "conflicts": true, "exclusive": true, "together": true,
 }
 
-func resolveFlagTag(structTag reflect.StructTag) flagTag {
+// resolveFlagTag parse the flag tag of field.
+// Return error for unknown keys and malformed values.
+func resolveFlagTag(structTag reflect.StructTag) (flagTag, error) {
 	tag, ok := structTag.Lookup("flag")
 	if !ok && structTag.Get("flag-usage") == "" {
-		return flagTag{enable: false}
+		return flagTag{enable: false}, nil
 	}
 
-	flagKV := make(map[string]string)
-
-	f := func(c rune) bool {
-		return unicode.IsSpace(c)
+	items, err := tokenizeTag(tag)
+	if err != nil {
+		return flagTag{}, errorw.Wrap(err, "parse flag tag").WithField("tag", tag)
 	}
-
-	// Splitting string by space but considering quoted section
-	items := strings.FieldsFunc(tag, f)
-	// Create and fill the map
+	flagKV := make(map[string]string)
 	for _, item := range items {
-		vals := strings.SplitN(item, tagKeySeparator, 2)
-
-		var key, value string
-		switch len(vals) {
-		case 1:
-			key = vals[0]
-		case 2:
-			key = vals[0]
-			value = vals[1]
+		if !tagKeys[item.key] {
+			return flagTag{}, errorw.NewMessagef("unknown flag tag key: %s", item.key).WithField("key", item.key)
 		}
-		if key != "" {
-			flagKV[key] = value
+		if _, ok := flagKV[item.key]; ok {
+			return flagTag{}, errorw.NewMessagef("duplicated flag tag key: %s", item.key).WithField("key", item.key)
 		}
+		flagKV[item.key] = item.value
 	}
 
 	// Fill struct
-	var flat, enableEnv, required bool
-	if v, ok := flagKV["flat"]; ok {
-		flat = parseBool(v)
+	bools := make(map[string]bool)
+	for _, key := range []string{"flat", "required", "config-file", "secret", "hidden", "nonempty"} {
+		v, ok := flagKV[key]
+		if !ok {
+			continue
+		}
+		bools[key], err = parseBool(v)
+		if err != nil {
+			return flagTag{}, errorw.NewMessagef("invalid value of flag tag %s, require a bool: %s", key, v).
+				WithField("key", key).
+				WithField("value", v)
+		}
 	}
+	var enableEnv bool
+	var envName string
 	if v, ok := flagKV["env"]; ok {
-		enableEnv = parseBool(v)
+		// Either a bool or an env name
+		enableEnv, err = parseBool(v)
+		if err != nil {
+			if !envNamePattern.MatchString(v) {
+				return flagTag{}, errorw.NewMessagef("invalid value of flag tag env, require a bool or an env name: %s", v).
+					WithField("key", "env").
+					WithField("value", v)
+			}
+			enableEnv = true
+			envName = v
+		}
 	}
-	if v, ok := flagKV["required"]; ok {
-		required = parseBool(v)
+	deprecated, ok := flagKV["deprecated"]
+	if ok && deprecated == "" {
+		deprecated = defaultDeprecatedMessage
 	}
 	var name *string
 	if v, ok := flagKV["name"]; ok {
 		name = newString(v)
 	}
+	shorthand := flagKV["short"]
+	if len(shorthand) > 1 {
+		return flagTag{}, errorw.NewMessagef("invalid value of flag tag short, require one character: %s", shorthand).
+			WithField("key", "short").
+			WithField("value", shorthand)
+	}
 
-	return flagTag{
-		enable:    true,
-		name:      name,
-		flat:      flat,
-		required:  required,
-		flagType:  flagKV["type"],
-		shorthand: flagKV["short"],
+	var itemCount int
+	if v, ok := flagKV["items"]; ok {
+		itemCount, err = strconv.Atoi(v)
+		if err != nil || itemCount < 0 {
+			return flagTag{}, errorw.NewMessagef("invalid value of flag tag items, require a non-negative integer: %s", v).
+				WithField("key", "items").
+				WithField("value", v)
+		}
+	}
+
+	usage, ok := flagKV["usage"]
+	if !ok {
 		// Prevent parse error since usage may have ','
-		usage:     structTag.Get("flag-usage"),
-		enableEnv: enableEnv,
-		envSplit:  flagKV["env-split"],
+		usage = structTag.Get("flag-usage")
+	}
+
+	return flagTag{
+		enable:     true,
+		name:       name,
+		flat:       bools["flat"],
+		required:   bools["required"],
+		flagType:   flagKV["type"],
+		shorthand:  shorthand,
+		usage:      usage,
+		enableEnv:  enableEnv,
+		envName:    envName,
+		envAliases: parseList(flagKV["env-alias"]),
+		envSplit:   flagKV["env-split"],
+		configFile: bools["config-file"],
+		items:      itemCount,
+		keys:       parseList(flagKV["keys"]),
+		secret:     bools["secret"],
+		hidden:     bools["hidden"],
+		deprecated: deprecated,
+		aliases:    parseList(flagKV["alias"]),
+		complete:   flagKV["complete"],
+		extensions: parseList(flagKV["ext"]),
+		rules: rules{
+			Min: flagKV["min"],
+			Max: flagKV["max"],
+			// enum is the same as oneof, and also completes values
+			OneOf:     append(parseList(flagKV["oneof"]), parseList(flagKV["enum"])...),
+			Regex:     flagKV["regex"],
+			NonEmpty:  bools["nonempty"],
+			Requires:  parseList(flagKV["requires"]),
+			Conflicts: parseList(flagKV["conflicts"]),
+			Exclusive: parseList(flagKV["exclusive"]),
+			Together:  parseList(flagKV["together"]),
+		},
+	}, nil
+}
+
+// checkTags return error of the first invalid flag tag of struct type and its nested structs,
+// with the path of field. e.g. Upstreams[].URL
+func checkTags(t reflect.Type, fieldPrefix string, depth int) error {
+	if depth > FlagMaxDepth {
+		return nil
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		// Unexported fields are skipped by resolver
+		if field.PkgPath != "" {
+			continue
+		}
+		fieldName := field.Name
+		if fieldPrefix != "" {
+			fieldName = fieldPrefix + "." + field.Name
+		}
+
+		tag, err := resolveFlagTag(field.Tag)
+		if err != nil {
+			return errorw.Wrap(err, "invalid flag tag").WithField("field", fieldName)
+		}
+		if !tag.enable || resolveCobraType(field, tag) != "" {
+			continue
+		}
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			err = checkTags(field.Type, fieldName, depth+1)
+		case isCollection(field.Type):
+			err = checkTags(field.Type.Elem(), fieldName+"[]", depth+1)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultDeprecatedMessage is the message of deprecated tag without value.
+const defaultDeprecatedMessage = "it will be removed in a future version"
+
+// tagItem is a key and its value of the flag tag.
+type tagItem struct {
+	key   string
+	value string
+}
+
+// tokenizeTag split tag into items by spaces, and split items into keys and values by the first "=".
+// Spaces and "=" which are quoted by ' or " are kept, and quotes are removed. e.g. usage='a b=c'
+// A backslash escapes quotes, spaces and backslashes, other backslashes are kept. e.g. regex=^\d+$
+func tokenizeTag(tag string) ([]tagItem, error) {
+	var items []tagItem
+	var sb strings.Builder
+	var item tagItem
+	var hasValue, started bool
+	var quote rune
+
+	flush := func() {
+		if !started {
+			return
+		}
+		if hasValue {
+			item.value = sb.String()
+		} else {
+			item.key = sb.String()
+		}
+		items = append(items, item)
+		sb.Reset()
+		item, hasValue, started = tagItem{}, false, false
+	}
+
+	runes := []rune(tag)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		if c == '\\' && i+1 < len(runes) {
+			next := runes[i+1]
+			escaped := next == '\\' || next == quote
+			if quote == 0 {
+				escaped = escaped || next == '"' || next == '\'' || unicode.IsSpace(next)
+			}
+			if escaped {
+				sb.WriteRune(next)
+				started = true
+				i++
+				continue
+			}
+		}
+
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+				continue
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			started = true
+			continue
+		case unicode.IsSpace(c):
+			flush()
+			continue
+		case c == '=' && !hasValue:
+			item.key = sb.String()
+			sb.Reset()
+			hasValue = true
+			started = true
+			continue
+		}
+		sb.WriteRune(c)
+		started = true
+	}
+	if quote != 0 {
+		return nil, errorw.NewMessagef("unterminated quote %c in flag tag", quote)
+	}
+	flush()
+	return items, nil
+}
+
+// parseList split value by "|"
+func parseList(v string) []string {
+	if v == "" {
+		return nil
 	}
+	return strings.Split(v, "|")
 }
 
-func parseBool(v string) bool {
+// parseBool return true for empty value, since the key without value means true.
+func parseBool(v string) (bool, error) {
 	if v == "" {
-		return true
+		return true, nil
 	}
-	result, _ := strconv.ParseBool(v)
-	return result
+	return strconv.ParseBool(v)
 }
 
 func newString(b string) *string {
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_resolveFlagTag(t *testing.T) {
@@ -39,6 +40,14 @@ func Test_resolveFlagTag(t *testing.T) {
 			structTag:       `flag:"flat env"`,
 			expectedFlagTag: flagTag{enable: true, flat: true, enableEnv: true},
 		},
+		{
+			structTag:       `flag:"env=false"`,
+			expectedFlagTag: flagTag{enable: true},
+		},
+		{
+			structTag:       `flag:"env=LEGACY_PORT env-alias=OLD_PORT|PORT"`,
+			expectedFlagTag: flagTag{enable: true, enableEnv: true, envName: "LEGACY_PORT", envAliases: []string{"OLD_PORT", "PORT"}},
+		},
 		{
 			structTag:       `flag-usage:"foo,&\n"`,
 			expectedFlagTag: flagTag{enable: true, usage: "foo,&\n"},
@@ -51,13 +60,88 @@ func Test_resolveFlagTag(t *testing.T) {
 			structTag:       `flag:"required"`,
 			expectedFlagTag: flagTag{enable: true, required: true},
 		},
+		{
+			structTag:       `flag:"complete=dir ext=yaml|json enum=a|b oneof=c"`,
+			expectedFlagTag: flagTag{enable: true, complete: "dir", extensions: []string{"yaml", "json"}, rules: rules{OneOf: []string{"c", "a", "b"}}},
+		},
+		{
+			structTag:       `flag:"hidden deprecated='use --new instead' alias=old|older"`,
+			expectedFlagTag: flagTag{enable: true, hidden: true, deprecated: "use --new instead", aliases: []string{"old", "older"}},
+		},
+		{
+			structTag:       `flag:"deprecated name=\"a b\""`,
+			expectedFlagTag: flagTag{enable: true, name: newString("a b"), deprecated: defaultDeprecatedMessage},
+		},
+		{
+			structTag:       `flag:"usage='a b, c=d'" flag-usage:"foo"`,
+			expectedFlagTag: flagTag{enable: true, usage: "a b, c=d"},
+		},
+		{
+			structTag:       `flag:"usage=a\\ \\\"b\\\" env-split=' ' regex='^\\d+\\\\$'"`,
+			expectedFlagTag: flagTag{enable: true, usage: `a "b"`, envSplit: " ", rules: rules{Regex: `^\d+\$`}},
+		},
+		{
+			structTag:       `flag:"name='' env=TRUE"`,
+			expectedFlagTag: flagTag{enable: true, name: newString(""), enableEnv: true},
+		},
+		{
+			structTag:       `flag:"items=3 keys=a|b"`,
+			expectedFlagTag: flagTag{enable: true, items: 3, keys: []string{"a", "b"}},
+		},
+		{
+			structTag:       `flag:"secret"`,
+			expectedFlagTag: flagTag{enable: true, secret: true},
+		},
+		{
+			structTag:       `flag:"config-file"`,
+			expectedFlagTag: flagTag{enable: true, configFile: true},
+		},
+		{
+			structTag: `flag:"min=1 max=10 oneof=a|b regex=^[a-z]+$ nonempty requires=foo conflicts=bar|baz"`,
+			expectedFlagTag: flagTag{enable: true, rules: rules{
+				Min: "1", Max: "10", OneOf: []string{"a", "b"}, Regex: "^[a-z]+$", NonEmpty: true,
+				Requires: []string{"foo"}, Conflicts: []string{"bar", "baz"},
+			}},
+		},
+		{
+			structTag: `flag:"exclusive=output together=auth|tls"`,
+			expectedFlagTag: flagTag{enable: true, rules: rules{
+				Exclusive: []string{"output"}, Together: []string{"auth", "tls"},
+			}},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.structTag, func(t *testing.T) {
-			result := resolveFlagTag(reflect.StructTag(tc.structTag))
+			result, err := resolveFlagTag(reflect.StructTag(tc.structTag))
+			require.NoError(t, err)
 
 			assert.Equal(t, tc.expectedFlagTag, result)
 		})
 	}
 }
+
+func Test_resolveFlagTagWithInvalid(t *testing.T) {
+	testCases := []struct {
+		structTag     string
+		expectedError string
+	}{
+		{structTag: `flag:"requird"`, expectedError: "unknown flag tag key: requird"},
+		{structTag: `flag:"=foo"`, expectedError: "unknown flag tag key: "},
+		{structTag: `flag:"env env=true"`, expectedError: "duplicated flag tag key: env"},
+		{structTag: `flag:"env=maybe"`, expectedError: "invalid value of flag tag env, require a bool or an env name: maybe"},
+		{structTag: `flag:"required=yes"`, expectedError: "invalid value of flag tag required, require a bool: yes"},
+		{structTag: `flag:"items=-1"`, expectedError: "invalid value of flag tag items, require a non-negative integer: -1"},
+		{structTag: `flag:"short=ab"`, expectedError: "invalid value of flag tag short, require one character: ab"},
+		{structTag: `flag:"usage='foo"`, expectedError: "parse flag tag: unterminated quote ' in flag tag"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.structTag, func(t *testing.T) {
+			_, err := resolveFlagTag(reflect.StructTag(tc.structTag))
+			require.Error(t, err)
+
+			assert.Contains(t, err.Error(), tc.expectedError)
+		})
+	}
+}
@@ -0,0 +1,369 @@
+package cmdutil
+
+import (
+	"encoding"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/XSAM/go-hybrid/errorw"
+)
+
+// FlagValueFunc create a pflag.Value which reads and writes value through pointer p.
+// p is a pointer to the type registered with the function.
+type FlagValueFunc func(p interface{}) pflag.Value
+
+type flagType struct {
+	typ         reflect.Type
+	newValue    FlagValueFunc
+	noOptDefVal string
+}
+
+var (
+	// Flag types by type name
+	flagTypes = make(map[string]flagType)
+	// Default type name by field type
+	flagTypeNames = make(map[reflect.Type]string)
+)
+
+var (
+	pflagValueType      = reflect.TypeOf((*pflag.Value)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// RegisterFlagType register a flag type which can be used by the type key of tag.
+// Fields of type typ will use this flag type by default.
+// It overwrites the registered flag type with the same name, and should be called before resolving flags.
+//
+//	cmdutil.RegisterFlagType("level", reflect.TypeOf(Level(0)), func(p interface{}) pflag.Value {
+//		return (*levelValue)(p.(*Level))
+//	})
+func RegisterFlagType(name string, typ reflect.Type, fn FlagValueFunc) {
+	registerFlagType(name, typ, fn, "")
+	if typ.Kind() != reflect.Interface {
+		flagTypeNames[typ] = name
+	}
+}
+
+func registerFlagType(name string, typ reflect.Type, fn FlagValueFunc, noOptDefVal string) {
+	flagTypes[name] = flagType{typ: typ, newValue: fn, noOptDefVal: noOptDefVal}
+	// The first registered name is the default name of this type
+	if _, ok := flagTypeNames[typ]; !ok && typ.Kind() != reflect.Interface {
+		flagTypeNames[typ] = name
+	}
+}
+
+// pflagValue create a pflag.Value with the built-in function of pflag.
+func pflagValue(register func(fs *pflag.FlagSet, name string)) pflag.Value {
+	fs := pflag.NewFlagSet("", pflag.ContinueOnError)
+	register(fs, "value")
+	return fs.Lookup("value").Value
+}
+
+func init() {
+	builtin := []struct {
+		name        string
+		sample      interface{}
+		noOptDefVal string
+		register    func(fs *pflag.FlagSet, name string, p interface{})
+	}{
+		{name: "bool", sample: false, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.BoolVar(p.(*bool), name, *p.(*bool), "")
+		}},
+		{name: "bool-slice", sample: []bool{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.BoolSliceVar(p.(*[]bool), name, *p.(*[]bool), "")
+		}},
+		{name: "string", sample: "", register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.StringVar(p.(*string), name, *p.(*string), "")
+		}},
+		{name: "string-slice", sample: []string{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.StringSliceVar(p.(*[]string), name, *p.(*[]string), "")
+		}},
+		{name: "string-array", sample: []string{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.StringArrayVar(p.(*[]string), name, *p.(*[]string), "")
+		}},
+		{name: "int", sample: 0, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.IntVar(p.(*int), name, *p.(*int), "")
+		}},
+		{name: "count", sample: 0, noOptDefVal: "+1", register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			// CountVar resets the value to zero
+			value := *p.(*int)
+			fs.CountVar(p.(*int), name, "")
+			*p.(*int) = value
+		}},
+		{name: "int8", sample: int8(0), register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.Int8Var(p.(*int8), name, *p.(*int8), "")
+		}},
+		{name: "int16", sample: int16(0), register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.Int16Var(p.(*int16), name, *p.(*int16), "")
+		}},
+		{name: "int32", sample: int32(0), register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.Int32Var(p.(*int32), name, *p.(*int32), "")
+		}},
+		{name: "int64", sample: int64(0), register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.Int64Var(p.(*int64), name, *p.(*int64), "")
+		}},
+		{name: "int-slice", sample: []int{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.IntSliceVar(p.(*[]int), name, *p.(*[]int), "")
+		}},
+		{name: "int32-slice", sample: []int32{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.Int32SliceVar(p.(*[]int32), name, *p.(*[]int32), "")
+		}},
+		{name: "int64-slice", sample: []int64{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.Int64SliceVar(p.(*[]int64), name, *p.(*[]int64), "")
+		}},
+		{name: "uint", sample: uint(0), register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.UintVar(p.(*uint), name, *p.(*uint), "")
+		}},
+		{name: "uint8", sample: uint8(0), register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.Uint8Var(p.(*uint8), name, *p.(*uint8), "")
+		}},
+		{name: "uint16", sample: uint16(0), register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.Uint16Var(p.(*uint16), name, *p.(*uint16), "")
+		}},
+		{name: "uint32", sample: uint32(0), register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.Uint32Var(p.(*uint32), name, *p.(*uint32), "")
+		}},
+		{name: "uint64", sample: uint64(0), register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.Uint64Var(p.(*uint64), name, *p.(*uint64), "")
+		}},
+		{name: "uint-slice", sample: []uint{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.UintSliceVar(p.(*[]uint), name, *p.(*[]uint), "")
+		}},
+		{name: "float32", sample: float32(0), register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.Float32Var(p.(*float32), name, *p.(*float32), "")
+		}},
+		{name: "float64", sample: float64(0), register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.Float64Var(p.(*float64), name, *p.(*float64), "")
+		}},
+		{name: "float32-slice", sample: []float32{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.Float32SliceVar(p.(*[]float32), name, *p.(*[]float32), "")
+		}},
+		{name: "float64-slice", sample: []float64{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.Float64SliceVar(p.(*[]float64), name, *p.(*[]float64), "")
+		}},
+		{name: "time.duration", sample: time.Duration(0), register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.DurationVar(p.(*time.Duration), name, *p.(*time.Duration), "")
+		}},
+		{name: "time.duration-slice", sample: []time.Duration{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.DurationSliceVar(p.(*[]time.Duration), name, *p.(*[]time.Duration), "")
+		}},
+		{name: "bytes-base64", sample: []byte{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.BytesBase64Var(p.(*[]byte), name, *p.(*[]byte), "")
+		}},
+		{name: "bytes-hex", sample: []byte{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.BytesHexVar(p.(*[]byte), name, *p.(*[]byte), "")
+		}},
+		{name: "net.ip", sample: net.IP{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.IPVar(p.(*net.IP), name, *p.(*net.IP), "")
+		}},
+		{name: "net.ip-slice", sample: []net.IP{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.IPSliceVar(p.(*[]net.IP), name, *p.(*[]net.IP), "")
+		}},
+		{name: "net.ip-mask", sample: net.IPMask{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.IPMaskVar(p.(*net.IPMask), name, *p.(*net.IPMask), "")
+		}},
+		{name: "net.ip-net", sample: net.IPNet{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.IPNetVar(p.(*net.IPNet), name, *p.(*net.IPNet), "")
+		}},
+		{name: "string-to-string", sample: map[string]string{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.StringToStringVar(p.(*map[string]string), name, *p.(*map[string]string), "")
+		}},
+		{name: "string-to-int", sample: map[string]int{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.StringToIntVar(p.(*map[string]int), name, *p.(*map[string]int), "")
+		}},
+		{name: "string-to-int64", sample: map[string]int64{}, register: func(fs *pflag.FlagSet, name string, p interface{}) {
+			fs.StringToInt64Var(p.(*map[string]int64), name, *p.(*map[string]int64), "")
+		}},
+	}
+	for _, v := range builtin {
+		register := v.register
+		registerFlagType(v.name, reflect.TypeOf(v.sample), func(p interface{}) pflag.Value {
+			return pflagValue(func(fs *pflag.FlagSet, name string) {
+				register(fs, name, p)
+			})
+		}, v.noOptDefVal)
+	}
+
+	registerFlagType("url.url", reflect.TypeOf(url.URL{}), func(p interface{}) pflag.Value {
+		return (*urlValue)(p.(*url.URL))
+	}, "")
+	registerFlagType("pflag.value", pflagValueType, func(p interface{}) pflag.Value {
+		return p.(pflag.Value)
+	}, "")
+	registerFlagType("encoding.text", textUnmarshalerType, func(p interface{}) pflag.Value {
+		return textValue{p: p.(encoding.TextUnmarshaler)}
+	}, "")
+}
+
+// typeName generate type name by kind for the type which isn't registered.
+// e.g. int-to-string
+func typeName(t reflect.Type) string {
+	if name, ok := flagTypeNames[t]; ok {
+		return name
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return t.Kind().String()
+	case reflect.Slice, reflect.Array:
+		if elemType := typeName(t.Elem()); elemType != "" {
+			return elemType + "-slice"
+		}
+	case reflect.Map:
+		keyType, valueType := typeName(t.Key()), typeName(t.Elem())
+		if keyType != "" && valueType != "" {
+			return fmt.Sprintf("%s-to-%s", keyType, valueType)
+		}
+	}
+	return ""
+}
+
+// newFlagValue create a pflag.Value of the flag type, which reads and writes value through pointer p.
+// Pointer p is converted to the registered type if the underlying types are identical.
+func newFlagValue(name string, p interface{}) (pflag.Value, flagType, error) {
+	ft, ok := flagTypes[name]
+	if !ok {
+		return nil, ft, errorw.NewMessagef("not supported flag type: %s", name)
+	}
+
+	pt := reflect.TypeOf(p)
+	switch {
+	case ft.match(pt):
+		return ft.value(p), ft, nil
+	case pt.Elem().Kind() == reflect.Ptr && ft.match(pt.Elem()):
+		return newPointerValue(ft, reflect.ValueOf(p).Elem()), ft, nil
+	case ft.typ.Kind() == reflect.Interface:
+		return nil, ft, errorw.NewMessagef("flag type %s require %s implements %s", name, pt, ft.typ)
+	default:
+		return nil, ft, errorw.NewMessagef("flag type %s does not match field type %s", name, pt.Elem())
+	}
+}
+
+// match return whether pointer type pt can be used by the flag type.
+func (ft flagType) match(pt reflect.Type) bool {
+	if ft.typ.Kind() == reflect.Interface {
+		return pt.Implements(ft.typ)
+	}
+	return pt.ConvertibleTo(reflect.PtrTo(ft.typ))
+}
+
+// value create a pflag.Value with pointer p which is converted to the registered type.
+func (ft flagType) value(p interface{}) pflag.Value {
+	if ft.typ.Kind() != reflect.Interface {
+		p = reflect.ValueOf(p).Convert(reflect.PtrTo(ft.typ)).Interface()
+	}
+	return ft.newValue(p)
+}
+
+// pointerValue sets value to the element of pointer field.
+// The field is allocated when the value is set, so it stays nil unless the value is set.
+type pointerValue struct {
+	ft    flagType
+	field reflect.Value
+	value pflag.Value
+	// Created with zero value, to provide the type of value
+	sample pflag.Value
+}
+
+func newPointerValue(ft flagType, field reflect.Value) pflag.Value {
+	v := pointerValue{
+		ft:     ft,
+		field:  field,
+		sample: ft.value(reflect.New(field.Type().Elem()).Interface()),
+	}
+	if !field.IsNil() {
+		v.value = ft.value(field.Interface())
+	}
+	// pflag treats values with IsBoolFlag as bool flags, even if it returns false
+	if bv, ok := v.sample.(interface{ IsBoolFlag() bool }); ok && bv.IsBoolFlag() {
+		return pointerBoolValue{&v}
+	}
+	return &v
+}
+
+// elem return the value of element, the field is allocated if it is nil.
+func (v *pointerValue) elem() pflag.Value {
+	if v.value == nil {
+		v.field.Set(reflect.New(v.field.Type().Elem()))
+		v.value = v.ft.value(v.field.Interface())
+	}
+	return v.value
+}
+
+func (v *pointerValue) Set(s string) error {
+	return v.elem().Set(s)
+}
+
+func (v *pointerValue) String() string {
+	if v.value == nil {
+		return ""
+	}
+	return v.value.String()
+}
+
+func (v *pointerValue) Type() string {
+	return v.sample.Type()
+}
+
+// pointerBoolValue is the pointerValue of bool flags, which can be used without value. e.g. --foo
+type pointerBoolValue struct {
+	*pointerValue
+}
+
+func (v pointerBoolValue) IsBoolFlag() bool {
+	return true
+}
+
+// urlValue adapts url.URL to pflag.Value
+type urlValue url.URL
+
+func (v *urlValue) Set(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	*v = urlValue(*u)
+	return nil
+}
+
+func (v *urlValue) String() string {
+	return (*url.URL)(v).String()
+}
+
+func (v *urlValue) Type() string {
+	return "url"
+}
+
+// textValue adapts encoding.TextUnmarshaler to pflag.Value
+type textValue struct {
+	p encoding.TextUnmarshaler
+}
+
+func (v textValue) Set(s string) error {
+	return v.p.UnmarshalText([]byte(s))
+}
+
+func (v textValue) String() string {
+	if m, ok := v.p.(encoding.TextMarshaler); ok {
+		if text, err := m.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	return fmt.Sprint(reflect.ValueOf(v.p).Elem().Interface())
+}
+
+func (v textValue) Type() string {
+	if name := reflect.TypeOf(v.p).Elem().Name(); name != "" {
+		return strings.ToLower(name)
+	}
+	return "value"
+}
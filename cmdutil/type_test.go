@@ -0,0 +1,211 @@
+package cmdutil
+
+import (
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+type testNamedSlice []string
+
+// testValue implements pflag.Value
+type testValue struct {
+	Values []string
+}
+
+func (v *testValue) Set(s string) error {
+	v.Values = append(v.Values, strings.ToUpper(s))
+	return nil
+}
+
+func (v *testValue) String() string {
+	return strings.Join(v.Values, ",")
+}
+
+func (v *testValue) Type() string {
+	return "upper"
+}
+
+type testPort int
+
+type testPortValue testPort
+
+func (v *testPortValue) Set(s string) error {
+	switch s {
+	case "http":
+		*v = 80
+	case "https":
+		*v = 443
+	default:
+		return assert.AnError
+	}
+	return nil
+}
+
+func (v *testPortValue) String() string {
+	return ""
+}
+
+func (v *testPortValue) Type() string {
+	return "port"
+}
+
+func TestResolveFlagVariableWithTypes(t *testing.T) {
+	m := struct {
+		Uint          uint             `flag:""`
+		Uint8         uint8            `flag:""`
+		Uint16        uint16           `flag:""`
+		Uint32        uint32           `flag:""`
+		Uint64        uint64           `flag:""`
+		Int8          int8             `flag:""`
+		Int16         int16            `flag:""`
+		Count         int              `flag:"type=count short=v"`
+		Float32       float32          `flag:""`
+		Float64       float64          `flag:""`
+		UintSlice     []uint           `flag:""`
+		Int32Slice    []int32          `flag:""`
+		Float64Slice  []float64        `flag:""`
+		StringArray   []string         `flag:"type=string-array"`
+		Bytes         []byte           `flag:""`
+		BytesHex      []byte           `flag:"type=bytes-hex"`
+		IP            net.IP           `flag:""`
+		IPSlice       []net.IP         `flag:""`
+		IPMask        net.IPMask       `flag:""`
+		IPNet         net.IPNet        `flag:""`
+		StringToInt64 map[string]int64 `flag:""`
+		URL           url.URL          `flag:""`
+		Named         testNamedSlice   `flag:""`
+		Value         testValue        `flag:""`
+		Level         zapcore.Level    `flag:""`
+		Bool          bool             `flag:""`
+		Duration      time.Duration    `flag:""`
+		StringToInt   map[string]int   `flag:""`
+	}{
+		Count: 1,
+		Level: zapcore.WarnLevel,
+	}
+
+	cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+	err := ResolveFlagVariable(&cmd, &m)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1", cmd.Flag("count").DefValue)
+	assert.Equal(t, "warn", cmd.Flag("level").DefValue)
+	assert.Equal(t, "upper", cmd.Flag("value").Value.Type())
+
+	_, _, err = executeCommandC(&cmd,
+		"--uint=1", "--uint8=2", "--uint16=3", "--uint32=4", "--uint64=5",
+		"--int8=-1", "--int16=-2", "-vv",
+		"--float32=1.5", "--float64=2.5",
+		"--uint-slice=1,2", "--int32-slice=3,4", "--float64-slice=0.5,1.5",
+		"--string-array=a,b", "--string-array=c",
+		"--bytes=aGVsbG8=", "--bytes-hex=776f726c64",
+		"--ip=127.0.0.1", "--ip-slice=10.0.0.1,10.0.0.2", "--ip-mask=255.255.255.0", "--ip-net=10.0.0.0/8",
+		"--string-to-int64=a=1", "--url=https://example.com/path",
+		"--named=a,b", "--value=a", "--value=b", "--level=debug", "--bool",
+	)
+	require.NoError(t, err)
+
+	_, ipNet, _ := net.ParseCIDR("10.0.0.0/8")
+	assert.Equal(t, uint(1), m.Uint)
+	assert.Equal(t, uint8(2), m.Uint8)
+	assert.Equal(t, uint16(3), m.Uint16)
+	assert.Equal(t, uint32(4), m.Uint32)
+	assert.Equal(t, uint64(5), m.Uint64)
+	assert.Equal(t, int8(-1), m.Int8)
+	assert.Equal(t, int16(-2), m.Int16)
+	assert.Equal(t, 3, m.Count)
+	assert.Equal(t, float32(1.5), m.Float32)
+	assert.Equal(t, 2.5, m.Float64)
+	assert.Equal(t, []uint{1, 2}, m.UintSlice)
+	assert.Equal(t, []int32{3, 4}, m.Int32Slice)
+	assert.Equal(t, []float64{0.5, 1.5}, m.Float64Slice)
+	assert.Equal(t, []string{"a,b", "c"}, m.StringArray)
+	assert.Equal(t, []byte("hello"), m.Bytes)
+	assert.Equal(t, []byte("world"), m.BytesHex)
+	assert.Equal(t, "127.0.0.1", m.IP.String())
+	assert.Len(t, m.IPSlice, 2)
+	assert.Equal(t, "ffffff00", m.IPMask.String())
+	assert.Equal(t, *ipNet, m.IPNet)
+	assert.Equal(t, map[string]int64{"a": 1}, m.StringToInt64)
+	assert.Equal(t, "https://example.com/path", m.URL.String())
+	assert.Equal(t, testNamedSlice{"a", "b"}, m.Named)
+	assert.Equal(t, []string{"A", "B"}, m.Value.Values)
+	assert.Equal(t, zapcore.DebugLevel, m.Level)
+	assert.True(t, m.Bool)
+}
+
+func TestRegisterFlagType(t *testing.T) {
+	RegisterFlagType("port", reflect.TypeOf(testPort(0)), func(p interface{}) pflag.Value {
+		return (*testPortValue)(p.(*testPort))
+	})
+	defer func() {
+		delete(flagTypes, "port")
+		delete(flagTypeNames, reflect.TypeOf(testPort(0)))
+	}()
+
+	m := struct {
+		Port testPort `flag:""`
+		// Use registered type by tag
+		Port2 int `flag:"type=port"`
+	}{}
+
+	cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+	err := ResolveFlagVariable(&cmd, &m)
+	require.NoError(t, err)
+
+	_, _, err = executeCommandC(&cmd, "--port=http", "--port2=https")
+	require.NoError(t, err)
+	assert.Equal(t, testPort(80), m.Port)
+	assert.Equal(t, 443, m.Port2)
+}
+
+func TestResolveFlagVariableWithMismatchedType(t *testing.T) {
+	testCases := []struct {
+		name          string
+		flag          interface{}
+		expectedError string
+	}{
+		{
+			name: "built-in type",
+			flag: &struct {
+				M string `flag:"type=int"`
+			}{},
+			expectedError: "flag type int does not match field type string",
+		},
+		{
+			name: "interface type",
+			flag: &struct {
+				M string `flag:"type=pflag.value"`
+			}{},
+			expectedError: "flag type pflag.value require *string implements pflag.Value",
+		},
+		{
+			name: "unknown type",
+			flag: &struct {
+				M string `flag:"type=foo"`
+			}{},
+			expectedError: "not supported flag type: foo",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := cobra.Command{}
+			err := ResolveFlagVariable(&cmd, tc.flag)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectedError)
+			// Nothing is registered
+			assert.False(t, cmd.HasAvailablePersistentFlags())
+		})
+	}
+}
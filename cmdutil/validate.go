@@ -0,0 +1,371 @@
+package cmdutil
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/XSAM/go-hybrid/errorw"
+)
+
+// rules of validation declared in tag
+type rules struct {
+	Min      string
+	Max      string
+	OneOf    []string
+	Regex    string
+	NonEmpty bool
+
+	// Full names of other flags
+	Requires  []string
+	Conflicts []string
+
+	// Names of flag groups
+	Exclusive []string
+	Together  []string
+}
+
+func (r rules) empty() bool {
+	return r.Min == "" && r.Max == "" && len(r.OneOf) == 0 && r.Regex == "" && !r.NonEmpty &&
+		len(r.Requires) == 0 && len(r.Conflicts) == 0
+}
+
+// validator validates the value of a flag by its rules.
+type validator struct {
+	flag flag
+
+	// Compare length instead of value
+	length   bool
+	min, max *float64
+	regex    *regexp.Regexp
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// fieldType return the type of field, pointer field return the type of its element.
+func fieldType(v flag) reflect.Type {
+	t := reflect.TypeOf(v.Pointer).Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// newValidators create validators for flags which have rules.
+func newValidators(flags flags) ([]*validator, error) {
+	names := make(map[string]struct{})
+	for _, v := range flags {
+		names[v.FullName] = struct{}{}
+	}
+
+	var validators []*validator
+	for _, v := range flags {
+		if v.Rules.empty() && !v.Required {
+			continue
+		}
+		vd, err := newValidator(v, names)
+		if err != nil {
+			return nil, errorw.Wrap(err, "invalid validation rule")
+		}
+		validators = append(validators, vd)
+	}
+	return validators, nil
+}
+
+func newValidator(v flag, names map[string]struct{}) (*validator, error) {
+	vd := validator{flag: v}
+	t := fieldType(v)
+
+	// Bounds
+	switch t.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		vd.length = true
+	}
+	parseBound := func(key, bound string) (*float64, error) {
+		if bound == "" {
+			return nil, nil
+		}
+
+		var result float64
+		switch {
+		case vd.length:
+			n, err := strconv.Atoi(bound)
+			if err != nil {
+				return nil, errorw.Wrapf(err, "parse %s", key).WithField("name", v.FullName)
+			}
+			result = float64(n)
+		case t == durationType:
+			d, err := time.ParseDuration(bound)
+			if err != nil {
+				return nil, errorw.Wrapf(err, "parse %s", key).WithField("name", v.FullName)
+			}
+			result = float64(d)
+		case isNumber(t.Kind()):
+			n, err := strconv.ParseFloat(bound, 64)
+			if err != nil {
+				return nil, errorw.Wrapf(err, "parse %s", key).WithField("name", v.FullName)
+			}
+			result = n
+		default:
+			return nil, errorw.NewMessagef("%s is not supported by type %s", key, t).WithField("name", v.FullName)
+		}
+		return &result, nil
+	}
+
+	var err error
+	if vd.min, err = parseBound("min", v.Rules.Min); err != nil {
+		return nil, err
+	}
+	if vd.max, err = parseBound("max", v.Rules.Max); err != nil {
+		return nil, err
+	}
+
+	if v.Rules.Regex != "" {
+		vd.regex, err = regexp.Compile(v.Rules.Regex)
+		if err != nil {
+			return nil, errorw.Wrap(err, "compile regex").WithField("name", v.FullName)
+		}
+	}
+
+	// Referenced flags must exist
+	for _, name := range append(append([]string{}, v.Rules.Requires...), v.Rules.Conflicts...) {
+		if _, ok := names[name]; !ok {
+			return nil, errorw.NewMessagef("referenced flag not found: %s", name).WithField("name", v.FullName)
+		}
+	}
+	return &vd, nil
+}
+
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// numberValue return the value of number kinds as float64.
+func numberValue(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
+
+// itemStrings return the string of each item for slices, otherwise the string of value.
+func itemStrings(v reflect.Value) []string {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		result := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			result = append(result, fmt.Sprint(v.Index(i).Interface()))
+		}
+		return result
+	}
+	return []string{fmt.Sprint(v.Interface())}
+}
+
+// validate return violations of the flag value.
+// Rules of values are not checked on optional flags which are not set, except nonempty,
+// so the zero value of an optional flag doesn't need to satisfy them.
+func (vd *validator) validate(b *binding) []string {
+	unset := b.source(vd.flag.FullName) == SourceDefault && !vd.flag.defaultItem()
+	if vd.flag.Required && unset {
+		if vd.flag.EnableEnv {
+			return []string{fmt.Sprintf("required flag --%s or env %s is not set", vd.flag.FullName, vd.flag.FullEnv)}
+		}
+		return []string{fmt.Sprintf("required flag --%s is not set", vd.flag.FullName)}
+	}
+
+	var violations []string
+	rules := vd.flag.Rules
+
+	value := reflect.ValueOf(vd.flag.Pointer).Elem()
+	if value.Kind() == reflect.Ptr {
+		// Nil pointer field means the value is not set
+		if value.IsNil() {
+			if rules.NonEmpty {
+				violations = append(violations, "must not be empty")
+			}
+			return append(violations, vd.validateReferences(b)...)
+		}
+		value = value.Elem()
+	}
+
+	if rules.NonEmpty {
+		if (vd.length && value.Len() == 0) || (!vd.length && value.IsZero()) {
+			violations = append(violations, "must not be empty")
+		}
+	}
+	if unset {
+		return violations
+	}
+
+	var current float64
+	var subject string
+	if vd.length {
+		subject = "length "
+		if value.Kind() == reflect.String {
+			current = float64(utf8.RuneCountInString(value.String()))
+		} else {
+			current = float64(value.Len())
+		}
+	} else if vd.min != nil || vd.max != nil {
+		current = numberValue(value)
+	}
+	if vd.min != nil && current < *vd.min {
+		violations = append(violations, fmt.Sprintf("%smust be at least %s", subject, rules.Min))
+	}
+	if vd.max != nil && current > *vd.max {
+		violations = append(violations, fmt.Sprintf("%smust be at most %s", subject, rules.Max))
+	}
+
+	if len(rules.OneOf) > 0 || vd.regex != nil {
+		for _, item := range itemStrings(value) {
+			if len(rules.OneOf) > 0 && !contains(rules.OneOf, item) {
+				violations = append(violations, fmt.Sprintf("%q is not one of %s", item, strings.Join(rules.OneOf, "|")))
+			}
+			if vd.regex != nil && !vd.regex.MatchString(item) {
+				violations = append(violations, fmt.Sprintf("%q does not match %s", item, rules.Regex))
+			}
+		}
+	}
+	return append(violations, vd.validateReferences(b)...)
+}
+
+// validateReferences check rules between flags.
+// A flag is treated as set if its value comes from a flag parameter, env or config file.
+func (vd *validator) validateReferences(b *binding) []string {
+	var violations []string
+	if b.source(vd.flag.FullName) == SourceDefault {
+		return nil
+	}
+
+	for _, name := range vd.flag.Rules.Requires {
+		if b.source(name) == SourceDefault {
+			violations = append(violations, fmt.Sprintf("requires flag %s", name))
+		}
+	}
+	for _, name := range vd.flag.Rules.Conflicts {
+		if b.source(name) != SourceDefault {
+			violations = append(violations, fmt.Sprintf("conflicts with flag %s", name))
+		}
+	}
+	return violations
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// flagGroup is the flags declared with the same group name.
+type flagGroup struct {
+	// e.g. exclusive:output
+	key       string
+	exclusive bool
+	// Full names of flags
+	names []string
+}
+
+// resolveGroups return mutually exclusive and required-together groups sorted by key.
+func resolveGroups(flags flags) []flagGroup {
+	groups := make(map[string]*flagGroup)
+	add := func(kind, name string, exclusive bool, fullName string) {
+		key := kind + ":" + name
+		if _, ok := groups[key]; !ok {
+			groups[key] = &flagGroup{key: key, exclusive: exclusive}
+		}
+		groups[key].names = append(groups[key].names, fullName)
+	}
+	for _, v := range flags {
+		for _, name := range v.Rules.Exclusive {
+			add("exclusive", name, true, v.FullName)
+		}
+		for _, name := range v.Rules.Together {
+			add("together", name, false, v.FullName)
+		}
+	}
+
+	result := make([]flagGroup, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].key < result[j].key
+	})
+	return result
+}
+
+// validate return the violation of group, empty if the group is valid.
+func (g flagGroup) validate(b *binding) string {
+	var set, unset []string
+	for _, name := range g.names {
+		if b.source(name) == SourceDefault {
+			unset = append(unset, name)
+		} else {
+			set = append(set, name)
+		}
+	}
+
+	if g.exclusive && len(set) > 1 {
+		return fmt.Sprintf("flags %s cannot be set together", strings.Join(set, ", "))
+	}
+	if !g.exclusive && len(set) > 0 && len(unset) > 0 {
+		return fmt.Sprintf("flags %s must be set together with %s", strings.Join(unset, ", "), strings.Join(set, ", "))
+	}
+	return ""
+}
+
+// validate check the values of all flags and flag groups.
+// Return an error which has a field for each invalid flag or group.
+func (b *binding) validate() error {
+	var err *errorw.Error
+	for _, vd := range b.validators {
+		// Unused items are not validated
+		if !vd.flag.active() {
+			continue
+		}
+		violations := vd.validate(b)
+		if len(violations) == 0 {
+			continue
+		}
+
+		if err == nil {
+			err = errorw.NewMessage("invalid flag value")
+		}
+		err = err.WithField(vd.flag.FullName, strings.Join(violations, "; "))
+	}
+
+	for _, g := range b.groups {
+		violation := g.validate(b)
+		if violation == "" {
+			continue
+		}
+
+		if err == nil {
+			err = errorw.NewMessage("invalid flag value")
+		}
+		err = err.WithField(g.key, violation)
+	}
+
+	if err == nil {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,245 @@
+package cmdutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/XSAM/go-hybrid/errorw"
+)
+
+type testValidateFlag struct {
+	Number   int           `flag:"min=1 max=10"`
+	Ratio    float64       `flag:"max=0.5"`
+	Duration time.Duration `flag:"min=1s"`
+	Name     string        `flag:"nonempty min=2 max=4 regex=^[a-z]+$"`
+	Mode     string        `flag:"oneof=dev|prod"`
+	Tags     []string      `flag:"oneof=a|b max=2"`
+	Pointer  *int          `flag:"min=1"`
+	Required *string       `flag:"nonempty"`
+
+	User     string `flag:"requires=password"`
+	Password string `flag:""`
+	Token    string `flag:"conflicts=user|password"`
+}
+
+func TestResolveFlagVariableWithValidation(t *testing.T) {
+	valid := []string{"--number=1", "--duration=1s", "--name=abc", "--mode=dev", "--required=x"}
+
+	testCases := []struct {
+		name               string
+		args               []string
+		expectedViolations map[string]interface{}
+	}{
+		{
+			name: "valid",
+			args: valid,
+		},
+		{
+			name: "valid with references",
+			args: append([]string{"--user=foo", "--password=bar", "--pointer=1"}, valid...),
+		},
+		{
+			name: "invalid",
+			args: []string{
+				"--number=11", "--ratio=0.6", "--duration=1ms", "--name=ABCDE", "--mode=test",
+				"--tags=a,c,b", "--pointer=0", "--user=foo", "--token=bar",
+			},
+			expectedViolations: map[string]interface{}{
+				"number":   "must be at most 10",
+				"ratio":    "must be at most 0.5",
+				"duration": "must be at least 1s",
+				"name":     `length must be at most 4; "ABCDE" does not match ^[a-z]+$`,
+				"mode":     `"test" is not one of dev|prod`,
+				"tags":     `length must be at most 2; "c" is not one of a|b`,
+				"pointer":  "must be at least 1",
+				"required": "must not be empty",
+				"user":     "requires flag password",
+				"token":    "conflicts with flag user",
+			},
+		},
+		{
+			name: "empty",
+			args: []string{"--required="},
+			expectedViolations: map[string]interface{}{
+				"name":     "must not be empty",
+				"required": "must not be empty",
+			},
+		},
+		{
+			name: "empty values",
+			args: []string{"--number=0", "--duration=0", "--name=", "--mode=", "--required=x"},
+			expectedViolations: map[string]interface{}{
+				"number":   "must be at least 1",
+				"duration": "must be at least 1s",
+				"name":     "must not be empty; length must be at least 2; \"\" does not match ^[a-z]+$",
+				"mode":     `"" is not one of dev|prod`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var f testValidateFlag
+			cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+			err := ResolveFlagVariable(&cmd, &f)
+			require.NoError(t, err)
+
+			_, _, err = executeCommandC(&cmd, tc.args...)
+			if tc.expectedViolations == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			e, ok := err.(*errorw.Error)
+			require.True(t, ok)
+			assert.Equal(t, "invalid flag value", e.Err.Error())
+			assert.Equal(t, tc.expectedViolations, e.Fields)
+		})
+	}
+}
+
+func TestResolveFlagVariableWithUnsetOptionalFlag(t *testing.T) {
+	var f struct {
+		Level  string   `flag:"enum=debug|info"`
+		Port   int      `flag:"min=1 max=65535"`
+		Name   string   `flag:"regex=^[a-z]+$"`
+		Tags   []string `flag:"oneof=a|b min=1"`
+		Config string   `flag:"config-file"`
+	}
+	cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+	require.NoError(t, ResolveFlagVariable(&cmd, &f))
+
+	_, _, err := executeCommandC(&cmd)
+	assert.NoError(t, err)
+
+	_, _, err = executeCommandC(&cmd, "--level=warn")
+	require.Error(t, err)
+	assert.Equal(t, map[string]interface{}{"level": `"warn" is not one of debug|info`}, err.(*errorw.Error).Fields)
+}
+
+func TestResolveFlagVariableWithValidationInSubCommand(t *testing.T) {
+	var f struct {
+		N int `flag:"min=5"`
+	}
+	root := &cobra.Command{Use: "root", Run: func(*cobra.Command, []string) {}}
+	sub := &cobra.Command{
+		Use:               "sub",
+		PersistentPreRunE: func(*cobra.Command, []string) error { return nil },
+		Run:               func(*cobra.Command, []string) {},
+	}
+	root.AddCommand(sub)
+	require.NoError(t, ResolveFlagVariable(root, &f))
+
+	_, _, err := executeCommandC(root, "sub", "--n=1")
+	require.Error(t, err)
+	assert.Equal(t, map[string]interface{}{"n": "must be at least 5"}, err.(*errorw.Error).Fields)
+
+	_, _, err = executeCommandC(root, "sub", "--n=5")
+	assert.NoError(t, err)
+}
+
+func TestResolveFlagVariableWithGroups(t *testing.T) {
+	testCases := []struct {
+		name               string
+		args               []string
+		expectedViolations map[string]interface{}
+	}{
+		{
+			name: "valid",
+			args: []string{"--json", "--user=foo", "--password=bar"},
+		},
+		{
+			name: "empty",
+		},
+		{
+			name: "invalid",
+			args: []string{"--json", "--yaml", "--password=bar"},
+			expectedViolations: map[string]interface{}{
+				"exclusive:output": "flags json, yaml cannot be set together",
+				"together:auth":    "flags user must be set together with password",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var f struct {
+				JSON     bool   `flag:"name=json exclusive=output"`
+				YAML     bool   `flag:"name=yaml exclusive=output"`
+				User     string `flag:"together=auth"`
+				Password string `flag:"together=auth"`
+			}
+			cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+			err := ResolveFlagVariable(&cmd, &f)
+			require.NoError(t, err)
+
+			_, _, err = executeCommandC(&cmd, tc.args...)
+			if tc.expectedViolations == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			e, ok := err.(*errorw.Error)
+			require.True(t, ok)
+			assert.Equal(t, tc.expectedViolations, e.Fields)
+		})
+	}
+}
+
+func TestResolveFlagVariableWithInvalidRule(t *testing.T) {
+	testCases := []struct {
+		name          string
+		flag          interface{}
+		expectedError string
+	}{
+		{
+			name: "invalid number",
+			flag: &struct {
+				M int `flag:"min=a"`
+			}{},
+			expectedError: "parse min",
+		},
+		{
+			name: "invalid duration",
+			flag: &struct {
+				M time.Duration `flag:"max=1"`
+			}{},
+			expectedError: "parse max",
+		},
+		{
+			name: "not supported type",
+			flag: &struct {
+				M bool `flag:"min=1"`
+			}{},
+			expectedError: "min is not supported by type bool",
+		},
+		{
+			name: "invalid regex",
+			flag: &struct {
+				M string `flag:"regex=["`
+			}{},
+			expectedError: "compile regex",
+		},
+		{
+			name: "referenced flag not found",
+			flag: &struct {
+				M string `flag:"requires=foo"`
+			}{},
+			expectedError: "referenced flag not found: foo",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ResolveFlagVariable(&cobra.Command{}, tc.flag)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectedError)
+		})
+	}
+}
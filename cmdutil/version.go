@@ -1,20 +1,133 @@
 package cmdutil
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/Masterminds/semver/v3"
 	"github.com/spf13/cobra"
-	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 
-	"github.com/XSAM/go-hybrid/log"
+	"github.com/XSAM/go-hybrid/errorw"
 	"github.com/XSAM/go-hybrid/metadata"
 )
 
+// Outputs of version command
+const (
+	VersionOutputText  = "text"
+	VersionOutputJSON  = "json"
+	VersionOutputYAML  = "yaml"
+	VersionOutputShort = "short"
+)
+
+// versionInfo is the application info with dependencies.
+// Keys of json and yaml are the same as metadata.Info in logs.
+type versionInfo struct {
+	metadata.Info `yaml:",inline"`
+	Dependencies  []metadata.Module `json:"Dependencies,omitempty" yaml:"Dependencies,omitempty"`
+}
+
+// VersionCmd return a command which print the application info and dependencies.
+// With --check, it prints nothing and returns error if the version doesn't satisfy the constraint.
+//
+//	app version --output=json
+//	app version --check=">=1.2.0, <2"
 func VersionCmd() *cobra.Command {
+	var output, check string
 	cmd := cobra.Command{
 		Use:   "version",
 		Short: "Print version information",
-		Run: func(cmd *cobra.Command, args []string) {
-			log.BgLogger().Info("application info", zap.Any("info", metadata.AppInfo()))
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := metadata.AppInfo()
+			if check != "" {
+				// The constraint is checked after usage, so usage is unnecessary for errors
+				cmd.SilenceUsage = true
+				return checkVersion(info.Version.GitVersion, check)
+			}
+
+			out := cmd.OutOrStdout()
+			v := versionInfo{Info: info, Dependencies: metadata.Dependencies()}
+			switch output {
+			case VersionOutputText:
+				return writeVersionText(out, v)
+			case VersionOutputJSON:
+				data, err := json.MarshalIndent(v, "", "  ")
+				if err != nil {
+					return errorw.Wrap(err, "marshal version info")
+				}
+				fmt.Fprintln(out, string(data))
+			case VersionOutputYAML:
+				encoder := yaml.NewEncoder(out)
+				encoder.SetIndent(2)
+				err := encoder.Encode(v)
+				if err != nil {
+					return errorw.Wrap(err, "marshal version info")
+				}
+				return encoder.Close()
+			case VersionOutputShort:
+				fmt.Fprintln(out, info.Version.GitVersion)
+			default:
+				return errorw.NewMessagef("not supported output: %s", output)
+			}
+			return nil
 		},
 	}
+	cmd.Flags().StringVarP(&output, "output", "o", VersionOutputText, "output format: text, json, yaml or short")
+	cmd.Flags().StringVar(&check, "check", "", `return error if the version doesn't satisfy the constraint, e.g. ">=1.2.0, <2"`)
 	return &cmd
 }
+
+func writeVersionText(w io.Writer, v versionInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	version := v.Version
+	for _, kv := range [][2]string{
+		{"App", v.AppName},
+		{"Version", version.GitVersion},
+		{"Git commit", version.GitCommit},
+		{"Git branch", version.GitBranch},
+		{"Git tree state", version.GitTreeState},
+		{"Build time", version.BuildTime},
+		{"Go version", version.GoVersion},
+		{"Compiler", version.Compiler},
+		{"Platform", version.Platform},
+	} {
+		fmt.Fprintf(tw, "%s:\t%s\n", kv[0], kv[1])
+	}
+	err := tw.Flush()
+	if err != nil || len(v.Dependencies) == 0 {
+		return err
+	}
+
+	// Align dependencies separately, since module paths are much longer
+	fmt.Fprintln(w, "Dependencies:")
+	tw = tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	for _, m := range v.Dependencies {
+		var replace string
+		if m.Replace != "" {
+			replace = " => " + m.Replace
+		}
+		fmt.Fprintf(tw, "  %s\t%s%s\n", m.Path, m.Version, replace)
+	}
+	return tw.Flush()
+}
+
+// checkVersion return error if version doesn't satisfy the constraint.
+// Constraints are parsed by github.com/Masterminds/semver, e.g. ">=1.2.0, <2.0.0 || ^3.1".
+func checkVersion(version, constraint string) error {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return errorw.Wrap(err, "parse version constraint").WithField("constraint", constraint)
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return errorw.Wrap(err, "parse application version").WithField("version", version)
+	}
+	if !c.Check(v) {
+		return errorw.NewMessagef("version %s does not satisfy %s", version, constraint).
+			WithField("version", version).
+			WithField("constraint", constraint)
+	}
+	return nil
+}
@@ -2,21 +2,25 @@ package cmdutil
 
 import (
 	"bytes"
+	"encoding/json"
+	"runtime"
 	"testing"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
+	"gopkg.in/yaml.v3"
 
 	"github.com/XSAM/go-hybrid/log"
+	"github.com/XSAM/go-hybrid/metadata"
 )
 
 func TestVersionCmd(t *testing.T) {
-	// Mock global logger
-	logger, logs := newObservedLogger()
-	log.SetBgLogger(logger)
+	metadata.SetAppName("app")
+	defer metadata.SetAppName("")
 
 	// Init cobra command
 	cmd := cobra.Command{}
@@ -26,9 +30,64 @@ func TestVersionCmd(t *testing.T) {
 	assert.Equal(t, true, cmd.HasAvailableSubCommands())
 
 	// Run command
-	_, _, err := executeCommandC(&cmd, "version")
-	assert.NoError(t, err)
-	assert.Contains(t, logs.All()[0].Message, "application info")
+	_, output, err := executeCommandC(&cmd, "version")
+	require.NoError(t, err)
+	assert.Contains(t, output, "App:            app\n")
+	assert.Contains(t, output, "Go version:     "+runtime.Version()+"\n")
+	assert.Contains(t, output, "Dependencies:\n")
+	assert.Contains(t, output, "  github.com/spf13/cobra ")
+
+	_, output, err = executeCommandC(&cmd, "version", "--output=json")
+	require.NoError(t, err)
+	var info map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(output), &info))
+	assert.Equal(t, "app", info["AppName"])
+	assert.Equal(t, runtime.Version(), info["Version"].(map[string]interface{})["GoVersion"])
+	assert.NotEmpty(t, info["Dependencies"])
+
+	// Keys of yaml are the same as json
+	_, output, err = executeCommandC(&cmd, "version", "--output=yaml")
+	require.NoError(t, err)
+	var yamlInfo map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(output), &yamlInfo))
+	assert.Equal(t, info, yamlInfo)
+
+	// Keys of json are the same as metadata.Info in logs
+	buf, err := zapcore.NewJSONEncoder(zapcore.EncoderConfig{}).
+		EncodeEntry(zapcore.Entry{}, []zap.Field{zap.Any("info", metadata.AppInfo())})
+	require.NoError(t, err)
+	var logged map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logged))
+	delete(info, "Dependencies")
+	assert.Equal(t, info, logged["info"])
+
+	_, output, err = executeCommandC(&cmd, "version", "--output=short")
+	require.NoError(t, err)
+	assert.Equal(t, metadata.AppInfo().Version.GitVersion+"\n", output)
+
+	_, _, err = executeCommandC(&cmd, "version", "--output=foo")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported output: foo")
+}
+
+func TestVersionCmdWithCheck(t *testing.T) {
+	cmd := cobra.Command{}
+	cmd.AddCommand(VersionCmd())
+
+	// Version isn't injected by ldflags in tests
+	_, output, err := executeCommandC(&cmd, "version", "--check=>=1.0.0")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parse application version")
+	assert.NotContains(t, output, "Usage:")
+
+	assert.NoError(t, checkVersion("v1.2.3", ">=1.2, <2"))
+	assert.NoError(t, checkVersion("v1.2.9", "~1.2.3"))
+	assert.NoError(t, checkVersion("v3.2.0", "^1.0 || ^3.1"))
+	err = checkVersion("v2.0.0", ">=1.2, <2")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "version v2.0.0 does not satisfy >=1.2, <2")
+	assert.Error(t, checkVersion("v1.0.0", "foo"))
+	assert.Error(t, checkVersion("v1.0.0", ">="))
 }
 
 func executeCommandC(root *cobra.Command, args ...string) (c *cobra.Command, output string, err error) {
@@ -0,0 +1,246 @@
+package cmdutil
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/XSAM/go-hybrid/errorw"
+	"github.com/XSAM/go-hybrid/log"
+	"github.com/XSAM/go-hybrid/log/zapfield"
+)
+
+// Change is the result of a reload.
+type Change struct {
+	// Pointer of the new copy of struct
+	Value interface{}
+	// Full names of flags which values are changed
+	Changed []string
+}
+
+// Watcher reloads the config file of a struct resolved by ResolveFlagVariable.
+// The struct itself is never modified by reloads, use Load or Subscribe to get the latest values.
+type Watcher struct {
+	b        *binding
+	interval time.Duration
+
+	// Pointer of the latest copy of struct
+	value atomic.Value
+
+	// Serialize reloads, and guard the stat of config file
+	reloadMu sync.Mutex
+	modTime  time.Time
+	size     int64
+
+	mu          sync.RWMutex
+	subscribers []func(Change)
+}
+
+// NewWatcher create a watcher for the struct which f points to.
+// f must be resolved by ResolveFlagVariable and has a config-file field.
+// It should be created after flags are parsed, e.g. in the Run of command.
+//
+//	w, err := cmdutil.NewWatcher(&flag, 5*time.Second)
+//	go w.Start(ctx)
+//	current := w.Load().(*Flag)
+func NewWatcher(f interface{}, interval time.Duration) (*Watcher, error) {
+	b := findBinding(f)
+	if b == nil {
+		return nil, errorw.NewMessage("struct is not resolved by ResolveFlagVariable")
+	}
+	if b.configFile() == nil {
+		return nil, errorw.NewMessage("watcher require a config file flag")
+	}
+	if interval <= 0 {
+		return nil, errorw.NewMessage("watcher require a positive interval").WithField("interval", interval.String())
+	}
+
+	w := Watcher{
+		b:        b,
+		interval: interval,
+	}
+	w.value.Store(copyStruct(f))
+	w.modTime, w.size = w.stat()
+	return &w, nil
+}
+
+// Load return the pointer of the latest copy of struct.
+func (w *Watcher) Load() interface{} {
+	return w.value.Load()
+}
+
+// Subscribe register a callback which is invoked after values are changed.
+func (w *Watcher) Subscribe(fn func(Change)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Start poll the config file until ctx is done.
+// Errors of reloads are logged, and the latest values are kept.
+func (w *Watcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.reloadIfModified()
+			if err != nil {
+				log.BgLogger().Error("reload config file", zapfield.Error(err))
+			}
+		}
+	}
+}
+
+// stat return the modification time and size of config file.
+func (w *Watcher) stat() (time.Time, int64) {
+	info, err := os.Stat(w.b.configFilePath())
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}
+
+// Reload read the config file, then publish the new values if they are valid and changed.
+func (w *Watcher) Reload() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	return w.reload()
+}
+
+// reloadIfModified reload if the modification time or size of config file is changed.
+func (w *Watcher) reloadIfModified() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	modTime, size := w.stat()
+	if modTime.Equal(w.modTime) && size == w.size {
+		return nil
+	}
+	return w.reload()
+}
+
+// reload is Reload which is called with reloadMu held.
+func (w *Watcher) reload() error {
+	w.modTime, w.size = w.stat()
+	value, err := w.b.reload()
+	if err != nil {
+		return err
+	}
+
+	changed := w.b.diff(w.Load(), value)
+	if len(changed) == 0 {
+		return nil
+	}
+	w.value.Store(value)
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, fn := range w.subscribers {
+		fn(Change{Value: value, Changed: changed})
+	}
+	return nil
+}
+
+// configFile return the config file flag, nil if not found.
+func (b *binding) configFile() *flag {
+	for i, v := range b.flags {
+		if v.ConfigFile {
+			return &b.flags[i]
+		}
+	}
+	return nil
+}
+
+func (b *binding) configFilePath() string {
+	return *b.configFile().Pointer.(*string)
+}
+
+// reload return a new copy of struct with the latest values of config file.
+// Values set by flag parameters and env are kept, others are reset to the default values before loading.
+func (b *binding) reload() (interface{}, error) {
+	value, fields, collections := b.resolveDefaults()
+
+	flags := make(flags, len(b.flags))
+	copy(flags, b.flags)
+	r := newBinding(pflag.NewFlagSet("reload", pflag.ContinueOnError), flags)
+	r.collections = collections
+	for i, v := range b.flags {
+		flags[i].Pointer = fields[i].Pointer
+		flags[i].Value = fields[i].Value
+		flags[i].Slots = fields[i].Slots
+
+		// Keep values with higher priority
+		if source := b.source(v.FullName); source == SourceFlag || source == SourceEnv {
+			reflect.ValueOf(flags[i].Pointer).Elem().Set(reflect.ValueOf(v.Pointer).Elem())
+			r.setSource(v.FullName, source)
+		}
+
+		f, err := newFlag(flags[i])
+		if err != nil {
+			return nil, err
+		}
+		r.flagSet.AddFlag(f)
+	}
+
+	var err error
+	r.validators, err = newValidators(flags)
+	if err != nil {
+		return nil, err
+	}
+	err = r.loadConfigFile(b.configFilePath())
+	if err != nil {
+		return nil, err
+	}
+	err = r.applyCollections()
+	if err != nil {
+		return nil, err
+	}
+	err = r.validate()
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// resolveDefaults return a copy of the default values, its flags which are the same as b.flags, and its collections.
+func (b *binding) resolveDefaults() (interface{}, flags, []*collection) {
+	value := copyStruct(b.defaults)
+	var collections []*collection
+	fields := resolver{mode: slotGrow, options: &b.options, collections: &collections}.resolve(value, nil, "", nil, "", nil, 1)
+	return value, fields, collections
+}
+
+// diff return full names of flags which values are different between two copies of struct.
+func (b *binding) diff(old, new interface{}) []string {
+	oldValues, newValues := b.flagValues(old), b.flagValues(new)
+
+	var changed []string
+	for _, v := range b.flags {
+		oldValue, oldOK := oldValues[v.FullName]
+		newValue, newOK := newValues[v.FullName]
+		if oldOK != newOK || !reflect.DeepEqual(oldValue, newValue) {
+			changed = append(changed, v.FullName)
+		}
+	}
+	return changed
+}
+
+// flagValues return values of struct keyed by full name.
+func (b *binding) flagValues(p interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for _, v := range (resolver{options: &b.options}).resolve(p, nil, "", nil, "", nil, 1) {
+		result[v.FullName] = reflect.ValueOf(v.Pointer).Elem().Interface()
+	}
+	return result
+}
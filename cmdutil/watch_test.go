@@ -0,0 +1,130 @@
+package cmdutil
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/XSAM/go-hybrid/errorw"
+)
+
+type testWatchFlag struct {
+	ConfigFile string `flag:"config-file"`
+	Number     int    `flag:"max=10"`
+	Name       string `flag:""`
+	Level      string `flag:""`
+}
+
+func newTestWatcher(t *testing.T, path string, args ...string) (*testWatchFlag, *Watcher) {
+	f := testWatchFlag{Number: 5}
+	cmd := cobra.Command{Run: func(*cobra.Command, []string) {}}
+	require.NoError(t, ResolveFlagVariable(&cmd, &f))
+
+	_, _, err := executeCommandC(&cmd, append([]string{"--config-file", path}, args...)...)
+	require.NoError(t, err)
+
+	w, err := NewWatcher(&f, 10*time.Millisecond)
+	require.NoError(t, err)
+	return &f, w
+}
+
+func TestWatcherReload(t *testing.T) {
+	path, cleanup := writeTempFile(t, "config.yaml", "number: 1\nname: config\n")
+	defer cleanup()
+
+	f, w := newTestWatcher(t, path, "--name=flag")
+	assert.Equal(t, f, w.Load())
+
+	var changes []Change
+	w.Subscribe(func(c Change) {
+		changes = append(changes, c)
+	})
+
+	// Flag parameter has higher priority
+	require.NoError(t, ioutil.WriteFile(path, []byte("number: 2\nname: config\nlevel: debug\n"), 0600))
+	require.NoError(t, w.Reload())
+	expected := testWatchFlag{ConfigFile: path, Number: 2, Name: "flag", Level: "debug"}
+	assert.Equal(t, &expected, w.Load())
+	require.Len(t, changes, 1)
+	assert.Equal(t, []string{"number", "level"}, changes[0].Changed)
+	assert.Equal(t, &expected, changes[0].Value)
+
+	// Bound struct is not modified
+	assert.Equal(t, 1, f.Number)
+
+	// Nothing changed
+	require.NoError(t, w.Reload())
+	assert.Len(t, changes, 1)
+
+	// Invalid values are not published
+	require.NoError(t, ioutil.WriteFile(path, []byte("number: 11\n"), 0600))
+	assert.Error(t, w.Reload())
+	assert.Equal(t, &expected, w.Load())
+
+	// Removed keys are reset to default values
+	require.NoError(t, ioutil.WriteFile(path, []byte("level: info\n"), 0600))
+	require.NoError(t, w.Reload())
+	assert.Equal(t, &testWatchFlag{ConfigFile: path, Number: 5, Name: "flag", Level: "info"}, w.Load())
+	require.Len(t, changes, 2)
+	assert.Equal(t, []string{"number", "level"}, changes[1].Changed)
+}
+
+func TestWatcherStart(t *testing.T) {
+	path, cleanup := writeTempFile(t, "config.yaml", "number: 1\n")
+	defer cleanup()
+
+	_, w := newTestWatcher(t, path)
+	changes := make(chan Change, 1)
+	w.Subscribe(func(c Change) {
+		changes <- c
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Start(ctx)
+
+	// Reload concurrently with polling
+	for i := 0; i < 5; i++ {
+		require.NoError(t, w.Reload())
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("number: 10\n"), 0600))
+	select {
+	case c := <-changes:
+		assert.Equal(t, []string{"number"}, c.Changed)
+		assert.Equal(t, 10, w.Load().(*testWatchFlag).Number)
+	case <-time.After(time.Second):
+		t.Fatal("config file is not reloaded")
+	}
+}
+
+func TestNewWatcherWithInvalid(t *testing.T) {
+	// Not resolved
+	_, err := NewWatcher(&testWatchFlag{}, time.Second)
+	assert.Error(t, err)
+
+	// Without config file flag
+	f := struct {
+		Number int `flag:""`
+	}{}
+	require.NoError(t, ResolveFlagVariable(&cobra.Command{}, &f))
+	_, err = NewWatcher(&f, time.Second)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "watcher require a config file flag")
+
+	// Non-positive interval
+	path, cleanup := writeTempFile(t, "config.yaml", "number: 1\n")
+	defer cleanup()
+	_, w := newTestWatcher(t, path)
+	for _, interval := range []time.Duration{0, -time.Second} {
+		_, err = NewWatcher(w.b.target, interval)
+		require.Error(t, err)
+		assert.Equal(t, "watcher require a positive interval", err.(*errorw.Error).Err.Error())
+	}
+}
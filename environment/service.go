@@ -19,6 +19,11 @@ func ProductionMode() {
 	Mode = ModeProduction
 }
 
+func StagingMode() {
+	gin.SetMode(gin.ReleaseMode)
+	Mode = ModeStaging
+}
+
 func JSONLogStyle() {
 	LogStyle = LogStyleJSON
 	switch Mode {
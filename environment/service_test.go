@@ -25,6 +25,12 @@ func TestPresetEnvironments(t *testing.T) {
 			expectedModeType:     ModeProduction,
 			expectedLogStyleType: LogStyleJSON,
 		},
+		{
+			name:                 "staging mode with json log style",
+			functions:            []func(){StagingMode, JSONLogStyle},
+			expectedModeType:     ModeStaging,
+			expectedLogStyleType: LogStyleJSON,
+		},
 		{
 			name:                 "development mode with text log style",
 			functions:            []func(){DevelopmentMode, TextLogStyle},
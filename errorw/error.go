@@ -15,9 +15,17 @@ type Error struct {
 	Err     error
 	Stack   *stack
 	Wrapper []string
-	Fields  map[string]interface{}
+	// Fields of all layers, fields of outer layers cover inner ones
+	Fields map[string]interface{}
+	// The origin and wraps of error, from inner to outer. Layers[i+1] is the wrap of Wrapper[i]
+	Layers []Layer
 
 	APIErrors []*status.Status
+
+	// Error which this error is copied from by With* methods
+	parent *Error
+	// With* methods and Wrap return a new error instead of modifying this one
+	immutable bool
 }
 
 type causer interface {
@@ -30,6 +38,7 @@ var _ stackTracer = (*Error)(nil)
 var _ causer = (*Error)(nil)
 var _ zapcore.ObjectMarshaler = (*Error)(nil)
 var _ interface{ GRPCStatus() *status.Status } = (*Error)(nil)
+var _ fmt.Formatter = (*Error)(nil)
 
 func (e *Error) Error() string {
 	return Render(e)
@@ -72,27 +81,94 @@ func (e *Error) GRPCStatus() *status.Status {
 	return nil
 }
 
+// Immutable mark e as copy-on-write and return it. Call it before e is shared, e.g. when a sentinel error is declared.
+// With* methods and Wrap of an immutable error return a new error which shares values with it,
+// so it can be used across goroutines, and errors derived from it are immutable too.
+// Other errors are modified in place by With* methods and Wrap.
+//
+//	var ErrNotFound = errorw.NewAPIError(status.New(codes.NotFound, "not found")).Immutable()
+func (e *Error) Immutable() *Error {
+	if e == nil {
+		return nil
+	}
+
+	e.immutable = true
+	return e
+}
+
+// clone return a copy of e which shares values with e, or e itself if e isn't immutable.
+// Slices are appended to new arrays, and fields are copied by setFields.
+func (e *Error) clone() *Error {
+	if !e.immutable {
+		return e
+	}
+
+	c := *e
+	c.parent = e
+	c.Wrapper = e.Wrapper[:len(e.Wrapper):len(e.Wrapper)]
+	c.APIErrors = e.APIErrors[:len(e.APIErrors):len(e.APIErrors)]
+	c.Layers = e.Layers[:len(e.Layers):len(e.Layers)]
+	return &c
+}
+
+// setFields add fields to e and its outermost layer. The map of fields is never kept.
+func (e *Error) setFields(fields map[string]interface{}) {
+	layers := e.layers()
+	if e.immutable {
+		// The outermost layer is shared with the parent
+		layers = append([]Layer(nil), layers...)
+	}
+	last := &layers[len(layers)-1]
+	last.Fields = mergeFields(last.Fields, fields, e.immutable)
+	e.Layers = layers
+	e.Fields = mergeFields(e.Fields, fields, e.immutable)
+}
+
+// mergeFields add fields to m. m is copied before writing if it is shared or nil.
+func mergeFields(m, fields map[string]interface{}, shared bool) map[string]interface{} {
+	if shared || m == nil {
+		c := make(map[string]interface{}, len(m)+len(fields))
+		for k, v := range m {
+			c[k] = v
+		}
+		m = c
+	}
+
+	for k, v := range fields {
+		m[k] = v
+	}
+	return m
+}
+
+// wrap append message and the caller frame to e.
+// skip is the number of frames to skip from the caller of wrap.
+func (e *Error) wrap(message string, skip int) *Error {
+	e = e.clone()
+	e.Layers = append(e.layers(), Layer{Message: message, Frame: caller(skip + 1)})
+	e.Wrapper = append(e.Wrapper, message)
+	return e
+}
+
 // WithAPIError append API error to error
 func (e *Error) WithAPIError(apiError *status.Status) *Error {
 	if e == nil {
 		return nil
 	}
-	
+
+	e = e.clone()
 	e.APIErrors = append(e.APIErrors, apiError)
 	return e
 }
 
-// WithField append key/value to error
+// WithField append key/value to error.
+// e is modified in place unless it is immutable, so mark errors shared by goroutines with Immutable first.
 func (e *Error) WithField(key string, value interface{}) *Error {
 	if e == nil {
 		return nil
 	}
-	
-	if e.Fields == nil {
-		e.Fields = make(map[string]interface{})
-	}
 
-	e.Fields[key] = value
+	e = e.clone()
+	e.setFields(map[string]interface{}{key: value})
 	return e
 }
 
@@ -102,14 +178,9 @@ func (e *Error) WithFields(fields map[string]interface{}) *Error {
 	if e == nil {
 		return nil
 	}
-	
-	if e.Fields == nil {
-		e.Fields = fields
-	} else {
-		for k, v := range fields {
-			e.Fields[k] = v
-		}
-	}
+
+	e = e.clone()
+	e.setFields(fields)
 	return e
 }
 
@@ -118,12 +189,13 @@ func (e *Error) WithWrap(message string) *Error {
 	if e == nil {
 		return nil
 	}
-	
-	e.Wrapper = append(e.Wrapper, message)
-	return e
+
+	return e.wrap(message, 1)
 }
 
-// New create an error
+// New create an error.
+// With* methods and Wrap modify the error in place, call Immutable before it is shared,
+// e.g. a package-level sentinel error which is wrapped in several goroutines.
 func New(err error) *Error {
 	return newError(err, 4)
 }
@@ -133,22 +205,29 @@ func newError(err error, skip int) *Error {
 		return nil
 	}
 
+	st := callers(skip)
+	var frame pkgerrors.Frame
+	if len(*st) > 0 {
+		frame = pkgerrors.Frame((*st)[0])
+	}
 	return &Error{
-		Err:   err,
-		Stack: callers(skip),
+		Err:    err,
+		Stack:  st,
+		Layers: []Layer{{Frame: frame}},
 	}
 }
 
-// Wrap wrap message
+// Wrap wrap message.
+// If err is an *Error, it is modified in place and returned unless it is immutable.
 func Wrap(err error, message string) *Error {
 	if err == nil {
 		return nil
 	}
 
 	if val, ok := err.(*Error); ok {
-		return val.WithWrap(message)
+		return val.wrap(message, 1)
 	}
-	return New(err).WithWrap(message)
+	return newError(err, 4).wrap(message, 1)
 }
 
 // Wrapf wrap message with formats.
@@ -159,9 +238,9 @@ func Wrapf(err error, format string, args ...interface{}) *Error {
 
 	message := fmt.Sprintf(format, args...)
 	if val, ok := err.(*Error); ok {
-		return val.WithWrap(message)
+		return val.wrap(message, 1)
 	}
-	return New(err).WithWrap(message)
+	return newError(err, 4).wrap(message, 1)
 }
 
 // NewMessage create an error with message.
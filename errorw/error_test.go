@@ -3,6 +3,7 @@ package errorw
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -273,3 +274,80 @@ func TestNewAPIError(t *testing.T) {
 	assert.Equal(t, apiErr, err.APIErrorCause())
 	assert.Equal(t, apiErr, err.GRPCStatus())
 }
+
+func TestError_Immutable(t *testing.T) {
+	sentinel := NewMessage("not found").
+		WithField("foo", "foo").
+		WithAPIError(status.New(codes.NotFound, "foo")).
+		Immutable()
+
+	err := Wrap(sentinel, "bar").
+		WithField("bar", "bar").
+		WithAPIError(status.New(codes.Internal, "bar"))
+
+	assert.Equal(t, "not found. fields: foo:foo", sentinel.Error())
+	assert.Len(t, sentinel.APIErrors, 1)
+	assert.Equal(t, []string{"bar"}, err.Wrapper)
+	assert.Equal(t, map[string]interface{}{"foo": "foo", "bar": "bar"}, err.Fields)
+	assert.Len(t, err.APIErrors, 2)
+	assert.True(t, errors.Is(err, sentinel))
+	assert.False(t, errors.Is(sentinel, err))
+
+	// Derived errors are immutable too
+	derived := err.WithField("baz", "baz")
+	assert.NotContains(t, err.Fields, "baz")
+	assert.True(t, errors.Is(derived, sentinel))
+
+	// Fields of caller are not kept
+	fields := map[string]interface{}{"foo": "foo"}
+	err = NewMessage("foo").Immutable().WithFields(fields).WithField("bar", "bar")
+	assert.Equal(t, map[string]interface{}{"foo": "foo"}, fields)
+
+}
+
+var errTestSentinel = NewMessage("not found").WithField("foo", "foo").Immutable()
+
+// Run with -race, immutable sentinel errors are shared across goroutines.
+func TestError_ImmutableConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			err := Wrapf(errTestSentinel, "wrap %d", i).
+				WithField("i", i).
+				WithFields(map[string]interface{}{"j": i}).
+				WithWrap("outer").
+				WithAPIError(status.New(codes.NotFound, "not found"))
+			err2 := Wrap(errTestSentinel, "wrap").WithField("i", i)
+			errTestSentinel.WithField("k", i).WithWrap("wrap")
+
+			assert.Equal(t, []string{fmt.Sprintf("wrap %d", i), "outer"}, err.Wrapper)
+			assert.Equal(t, map[string]interface{}{"foo": "foo", "i": i, "j": i}, err.Fields)
+			assert.Equal(t, map[string]interface{}{"foo": "foo", "i": i}, err2.Fields)
+			assert.True(t, errors.Is(err, errTestSentinel))
+			_ = err.Error()
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Empty(t, errTestSentinel.Wrapper)
+	assert.Empty(t, errTestSentinel.APIErrors)
+	assert.Equal(t, map[string]interface{}{"foo": "foo"}, errTestSentinel.Fields)
+	assert.Equal(t, "not found. fields: foo:foo", errTestSentinel.Error())
+}
+
+func TestError_InPlace(t *testing.T) {
+	err := NewMessage("foo")
+	err.WithField("foo", "foo").WithWrap("bar")
+	assert.Equal(t, "bar: foo. fields: foo:foo", err.Error())
+	// Not safe to share without Immutable
+	assert.Same(t, err, Wrap(err, "baz"))
+	assert.Same(t, err, err.WithField("baz", "baz"))
+
+	// Fields of caller are not kept
+	fields := map[string]interface{}{"foo": "foo"}
+	NewMessage("foo").WithFields(fields).WithField("bar", "bar")
+	assert.Equal(t, map[string]interface{}{"foo": "foo"}, fields)
+}
@@ -0,0 +1,114 @@
+package errorw
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Format implement `fmt.Formatter` interface.
+//
+//	%s, %v  the message rendered by Render
+//	%q      the quoted message
+//	%+v     the message, fields, API errors, wrappers with their callers and fields, and the stack trace
+//	%#v     a Go-syntax representation with callers and API errors, for debugging
+func (e *Error) Format(s fmt.State, verb rune) {
+	if e == nil {
+		io.WriteString(s, "<nil>")
+		return
+	}
+
+	switch verb {
+	case 'v':
+		switch {
+		case s.Flag('+'):
+			e.formatDetail(s)
+		case s.Flag('#'):
+			e.formatGoSyntax(s)
+		default:
+			io.WriteString(s, e.Error())
+		}
+	case 's':
+		io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	}
+}
+
+// formatDetail print the message and details of e.
+//
+//	find user: query: sql: no rows in result set
+//	fields: id:42 table:users
+//	api errors:
+//		NotFound: user not found
+//	wrappers:
+//		find user id:42
+//			main.findUser
+//				/path/to/user.go:20
+//		...
+//	stack:
+//	main.query
+//		/path/to/db.go:8
+//	...
+func (e *Error) formatDetail(w io.Writer) {
+	var messages []string
+	for i := len(e.Wrapper) - 1; i >= 0; i-- {
+		messages = append(messages, e.Wrapper[i])
+	}
+	origin := "nil"
+	if e.Err != nil {
+		origin = e.Err.Error()
+	}
+	io.WriteString(w, strings.Join(append(messages, origin), ": "))
+
+	if len(e.Fields) > 0 {
+		fmt.Fprintf(w, "\nfields:%s", sortedFields(e.Fields))
+	}
+	if len(e.APIErrors) > 0 {
+		io.WriteString(w, "\napi errors:")
+		for _, st := range e.APIErrors {
+			fmt.Fprintf(w, "\n\t%s: %s", st.Code(), st.Message())
+		}
+	}
+
+	io.WriteString(w, "\nwrappers:")
+	layers := e.layers()
+	for i := len(layers) - 1; i >= 0; i-- {
+		message := layers[i].Message
+		if i == 0 {
+			message = origin
+		}
+		function, file, line := location(layers[i].Frame)
+		fmt.Fprintf(w, "\n\t%s%s\n\t\t%s\n\t\t\t%s:%d", message, sortedFields(layers[i].Fields), function, file, line)
+	}
+
+	if e.Stack != nil {
+		fmt.Fprintf(w, "\nstack:%+v", e.Stack)
+	}
+}
+
+// formatGoSyntax print e like %#v, with callers rather than program counters,
+// and API errors as the calls which create them.
+func (e *Error) formatGoSyntax(w io.Writer) {
+	apiErrors := make([]string, 0, len(e.APIErrors))
+	for _, st := range e.APIErrors {
+		apiErrors = append(apiErrors, fmt.Sprintf("status.New(codes.%s, %q)", st.Code(), st.Message()))
+	}
+	var stack []string
+	if e.Stack != nil {
+		for _, f := range e.StackTrace() {
+			stack = append(stack, frameString(f))
+		}
+	}
+
+	fmt.Fprintf(w, "&errorw.Error{Err:%#v, Wrapper:%#v, Fields:%#v, Layers:%#v, APIErrors:[]*status.Status{%s}, Stack:%#v}",
+		e.Err, e.Wrapper, e.Fields, e.Layers, strings.Join(apiErrors, ", "), stack)
+}
+
+// frameString return the function, file and line of frame. e.g. "main.main /path/to/main.go:10"
+func frameString(f errors.Frame) string {
+	function, file, line := location(f)
+	return fmt.Sprintf("%s %s:%d", function, file, line)
+}
@@ -0,0 +1,84 @@
+package errorw
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testFormatError() *Error {
+	return New(errors.New("origin")).
+		WithField("a", 1).
+		WithAPIError(status.New(codes.NotFound, "not found"))
+}
+
+func TestError_Format(t *testing.T) {
+	err := Wrap(testFormatError(), "wrap").WithField("b", "x")
+
+	testCases := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "%s",
+			want:   "^wrap: origin. fields: (a:1 b:x|b:x a:1)$",
+		},
+		{
+			format: "%v",
+			want:   "^wrap: origin. fields: (a:1 b:x|b:x a:1)$",
+		},
+		{
+			format: "%q",
+			want:   `^"wrap: origin. fields: (a:1 b:x|b:x a:1)"$`,
+		},
+		{
+			format: "%+v",
+			want: "^wrap: origin\n" +
+				"fields: a:1 b:x\n" +
+				"api errors:\n" +
+				"\tNotFound: not found\n" +
+				"wrappers:\n" +
+				"\twrap b:x\n" +
+				"\t\tgithub.com/XSAM/go-hybrid/errorw.TestError_Format\n" +
+				"\t\t\t.*go-hybrid/errorw/format_test.go:21\n" +
+				"\torigin a:1\n" +
+				"\t\tgithub.com/XSAM/go-hybrid/errorw.testFormatError\n" +
+				"\t\t\t.*go-hybrid/errorw/format_test.go:15\n" +
+				"stack:\n" +
+				"github.com/XSAM/go-hybrid/errorw.testFormatError\n" +
+				"\t.*go-hybrid/errorw/format_test.go:15\n" +
+				"github.com/XSAM/go-hybrid/errorw.TestError_Format\n" +
+				"\t.*go-hybrid/errorw/format_test.go:21\n",
+		},
+		{
+			format: "%#v",
+			want: `^&errorw.Error\{Err:&errors.errorString\{s:"origin"\}, Wrapper:\[\]string\{"wrap"\}, ` +
+				`Fields:map\[string\]interface \{\}\{"a":1, "b":"x"\}, ` +
+				`Layers:\[\]errorw.Layer\{` +
+				`errorw.Layer\{Message:"", Frame:"github.com/XSAM/go-hybrid/errorw.testFormatError .*go-hybrid/errorw/format_test.go:15", Fields:map\[string\]interface \{\}\{"a":1\}\}, ` +
+				`errorw.Layer\{Message:"wrap", Frame:"github.com/XSAM/go-hybrid/errorw.TestError_Format .*go-hybrid/errorw/format_test.go:21", Fields:map\[string\]interface \{\}\{"b":"x"\}\}\}, ` +
+				`APIErrors:\[\]\*status.Status\{status.New\(codes.NotFound, "not found"\)\}, ` +
+				`Stack:\[\]string\{"github.com/XSAM/go-hybrid/errorw.testFormatError .*go-hybrid/errorw/format_test.go:15", `,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			assert.Regexp(t, regexp.MustCompile(tc.want), fmt.Sprintf(tc.format, err))
+		})
+	}
+}
+
+func TestError_FormatWithoutDetails(t *testing.T) {
+	err := &Error{Wrapper: []string{"wrap"}}
+
+	assert.Equal(t, "wrap: nil\nwrappers:\n\twrap\n\t\tunknown\n\t\t\tunknown:0\n\tnil\n\t\tunknown\n\t\t\tunknown:0",
+		fmt.Sprintf("%+v", err))
+	assert.Equal(t, "<nil>", fmt.Sprintf("%+v", (*Error)(nil)))
+	assert.Equal(t, "<nil>", fmt.Sprintf("%s", (*Error)(nil)))
+}
@@ -0,0 +1,133 @@
+package errorw
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type grpcStatuser interface {
+	GRPCStatus() *status.Status
+}
+
+// Unwrap return the internal error, so `errors.Is` and `errors.As` walk through the error chain.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is report whether e is copied from target by With* methods or Wrap,
+// or an API error of e matches the gRPC status of target.
+// Codes must be the same, and messages too unless the message of target is empty.
+// Implement `errors.Is` interface. e.g. errors.Is(err, status.Error(codes.NotFound, ""))
+func (e *Error) Is(target error) bool {
+	// Errors of errorw are matched by identity
+	if t, ok := target.(*Error); ok {
+		for p := e.parent; p != nil; p = p.parent {
+			if p == t {
+				return true
+			}
+		}
+		return false
+	}
+	se, ok := target.(grpcStatuser)
+	if !ok {
+		return false
+	}
+	st := se.GRPCStatus()
+	if st == nil {
+		return false
+	}
+
+	for _, apiError := range e.APIErrors {
+		if apiError.Code() == st.Code() && (st.Message() == "" || apiError.Message() == st.Message()) {
+			return true
+		}
+	}
+	return false
+}
+
+// As find the first API error which matches target, and set target to its gRPC status error.
+// Implement `errors.As` interface.
+func (e *Error) As(target interface{}) bool {
+	for _, apiError := range e.APIErrors {
+		if err := apiError.Err(); err != nil && errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Is report whether any error in the chain of err matches target.
+// target is either an error, which is matched like `errors.Is`,
+// or a gRPC code, which is matched against API errors and gRPC status of errors in the chain.
+// All errors of Multi are matched.
+// Errors wrapped by `github.com/pkg/errors` are walked through too.
+//
+//	errorw.Is(err, sql.ErrNoRows)
+//	errorw.Is(err, codes.NotFound)
+func Is(err error, target interface{}) bool {
+	switch target := target.(type) {
+	case codes.Code:
+		for ; err != nil; err = unwrap(err) {
+			switch e := err.(type) {
+			case *Error:
+				if e.hasCode(target) {
+					return true
+				}
+				continue
+			case *Multi:
+				for _, member := range e.Errors {
+					if Is(member, target) {
+						return true
+					}
+				}
+				return false
+			}
+			if se, ok := err.(grpcStatuser); ok && se.GRPCStatus().Code() == target {
+				return true
+			}
+		}
+	case error:
+		for ; err != nil; err = unwrap(err) {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// As find the first error in the chain of err which matches target, and set target to it.
+// It is the same as `errors.As`, except errors wrapped by `github.com/pkg/errors` are walked through too.
+func As(err error, target interface{}) bool {
+	for ; err != nil; err = unwrap(err) {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasCode report whether e has an API error with the code.
+func (e *Error) hasCode(code codes.Code) bool {
+	for _, apiError := range e.APIErrors {
+		if apiError.Code() == code {
+			return true
+		}
+	}
+	return false
+}
+
+// unwrap return the next error of the chain, by `Unwrap` or `Cause` of `github.com/pkg/errors`.
+func unwrap(err error) error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	case causer:
+		if cause := e.Cause(); cause != err {
+			return cause
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package errorw
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestError_Unwrap(t *testing.T) {
+	err := Wrap(fmt.Errorf("query: %w", sql.ErrNoRows), "foo")
+
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.True(t, errors.Is(Wrap(err, "bar"), sql.ErrNoRows))
+	assert.True(t, errors.Is(fmt.Errorf("wrap: %w", err), sql.ErrNoRows))
+	assert.False(t, errors.Is(err, errors.New("sql: no rows in result set")))
+
+	var e *Error
+	assert.True(t, errors.As(fmt.Errorf("wrap: %w", err), &e))
+	assert.Equal(t, err, e)
+}
+
+func TestError_Is(t *testing.T) {
+	err := NewMessage("foo").
+		WithAPIError(status.New(codes.NotFound, "user not found")).
+		WithAPIError(status.New(codes.Internal, "bar"))
+
+	testCases := []struct {
+		name     string
+		target   error
+		expected bool
+	}{
+		{
+			name:     "same code",
+			target:   status.Error(codes.NotFound, ""),
+			expected: true,
+		},
+		{
+			name:     "same code and message",
+			target:   status.Error(codes.NotFound, "user not found"),
+			expected: true,
+		},
+		{
+			name:   "different message",
+			target: status.Error(codes.NotFound, "order not found"),
+		},
+		{
+			name:   "different code",
+			target: status.Error(codes.PermissionDenied, ""),
+		},
+		{
+			name:   "errorw.Error with the same API error",
+			target: NewAPIError(status.New(codes.NotFound, "user not found")),
+		},
+		{
+			name:   "not gRPC status",
+			target: errors.New("foo"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, errors.Is(err, tc.target))
+		})
+	}
+
+	// Identity
+	assert.True(t, errors.Is(fmt.Errorf("wrap: %w", err), err))
+}
+
+func TestError_As(t *testing.T) {
+	err := fmt.Errorf("wrap: %w", NewAPIError(status.New(codes.NotFound, "foo")))
+
+	var se interface {
+		error
+		GRPCStatus() *status.Status
+	}
+	assert.True(t, errors.As(err, &se))
+	assert.Equal(t, codes.NotFound, se.GRPCStatus().Code())
+
+	var target *testAsError
+	assert.False(t, errors.As(err, &target))
+}
+
+type testAsError struct{}
+
+func (*testAsError) Error() string {
+	return "test"
+}
+
+func TestIs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		target   interface{}
+		expected bool
+	}{
+		{
+			name:     "nil",
+			target:   codes.NotFound,
+			expected: false,
+		},
+		{
+			name:     "code of API error",
+			err:      Wrap(NewAPIError(status.New(codes.NotFound, "foo")), "bar"),
+			target:   codes.NotFound,
+			expected: true,
+		},
+		{
+			name:     "code of API error wrapped by fmt",
+			err:      fmt.Errorf("wrap: %w", NewAPIError(status.New(codes.NotFound, "foo"))),
+			target:   codes.NotFound,
+			expected: true,
+		},
+		{
+			name:     "code of internal gRPC status error",
+			err:      Wrap(status.Error(codes.NotFound, "foo"), "bar"),
+			target:   codes.NotFound,
+			expected: true,
+		},
+		{
+			name:     "code of gRPC status error wrapped by pkg/errors",
+			err:      Wrap(pkgerrors.Wrap(status.Error(codes.NotFound, "foo"), "bar"), "baz"),
+			target:   codes.NotFound,
+			expected: true,
+		},
+		{
+			name:     "internal code is not implied",
+			err:      NewMessage("foo"),
+			target:   codes.Internal,
+			expected: false,
+		},
+		{
+			name:     "different code",
+			err:      NewAPIError(status.New(codes.NotFound, "foo")),
+			target:   codes.PermissionDenied,
+			expected: false,
+		},
+		{
+			name:     "error",
+			err:      Wrap(sql.ErrNoRows, "foo"),
+			target:   sql.ErrNoRows,
+			expected: true,
+		},
+		{
+			name:     "error wrapped by pkg/errors",
+			err:      Wrap(pkgerrors.Wrap(sql.ErrNoRows, "foo"), "bar"),
+			target:   sql.ErrNoRows,
+			expected: true,
+		},
+		{
+			name:     "not supported target",
+			err:      Wrap(sql.ErrNoRows, "foo"),
+			target:   "sql: no rows in result set",
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Is(tc.err, tc.target))
+		})
+	}
+}
+
+func TestAs(t *testing.T) {
+	root := &testAsError{}
+	err := Wrap(pkgerrors.Wrap(root, "foo"), "bar")
+
+	var target *testAsError
+	assert.True(t, As(err, &target))
+	assert.Equal(t, root, target)
+
+	// errors.As doesn't walk through pkg/errors v0.8
+	target = nil
+	assert.False(t, errors.As(err, &target))
+	assert.False(t, As(nil, &target))
+}
@@ -0,0 +1,111 @@
+package errorw
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Layer is the origin or a wrap of an error, with the caller frame and the fields which are added at this layer.
+type Layer struct {
+	// Wrap message, empty for the origin
+	Message string
+	// Caller which creates or wraps the error
+	Frame errors.Frame
+	// Fields which are added after the error is created or wrapped, before the next wrap
+	Fields map[string]interface{}
+}
+
+// Verify interface compliance at compile time
+var _ fmt.Formatter = Layer{}
+var _ zapcore.ObjectMarshaler = Layer{}
+
+// caller return the frame of the caller. skip 0 is the function which calls caller.
+func caller(skip int) errors.Frame {
+	var pcs [1]uintptr
+	runtime.Callers(skip+2, pcs[:])
+	return errors.Frame(pcs[0])
+}
+
+// location return the function name, file and line of frame.
+func location(f errors.Frame) (string, string, int) {
+	pc := uintptr(f) - 1
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "unknown", "unknown", 0
+	}
+	file, line := fn.FileLine(pc)
+	return fn.Name(), file, line
+}
+
+// layers return layers of e. Layers of errors which are not created by New, e.g. &Error{},
+// are rebuilt from wrappers without frames, and fields belong to the origin.
+func (e *Error) layers() []Layer {
+	if len(e.Layers) == len(e.Wrapper)+1 {
+		return e.Layers
+	}
+
+	layers := make([]Layer, len(e.Wrapper)+1)
+	// Fields of e are modified in place by With* methods, so the origin layer doesn't share the map
+	if e.Fields != nil {
+		layers[0].Fields = mergeFields(nil, e.Fields, true)
+	}
+	for i, message := range e.Wrapper {
+		layers[i+1].Message = message
+	}
+	return layers
+}
+
+// Format print the message with %s and %v, and also the caller with %+v.
+// %#v print a Go-syntax representation with the caller rather than the program counter.
+//
+//	wrap message
+//	    github.com/foo/bar.Baz
+//	        /path/to/bar.go:42
+func (l Layer) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		switch {
+		case s.Flag('+'):
+			function, file, line := location(l.Frame)
+			fmt.Fprintf(s, "%s\n\t%s\n\t\t%s:%d", l.Message, function, file, line)
+			return
+		case s.Flag('#'):
+			fmt.Fprintf(s, "errorw.Layer{Message:%q, Frame:%q, Fields:%#v}", l.Message, frameString(l.Frame), l.Fields)
+			return
+		}
+		fallthrough
+	case 's':
+		fmt.Fprint(s, l.Message)
+	}
+}
+
+// MarshalLogObject is an implementation of `zapcore.ObjectMarshaler` interface
+func (l Layer) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if l.Message != "" {
+		enc.AddString("msg", l.Message)
+	}
+	function, file, line := location(l.Frame)
+	enc.AddString("func", function)
+	enc.AddString("caller", fmt.Sprintf("%s:%d", file, line))
+	if len(l.Fields) > 0 {
+		field := zap.Any("fields", l.Fields)
+		field.AddTo(enc)
+	}
+	return nil
+}
+
+type layers []Layer
+
+func (ls layers) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, l := range ls {
+		err := enc.AppendObject(l)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
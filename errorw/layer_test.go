@@ -0,0 +1,92 @@
+package errorw
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLayerOrigin() *Error {
+	return NewMessage("origin").WithField("foo", "foo")
+}
+
+func testLayerWrap(err error) *Error {
+	return Wrap(err, "wrap").WithField("bar", "bar").WithField("foo", "foo2")
+}
+
+func TestError_Layers(t *testing.T) {
+	err := testLayerWrap(testLayerOrigin()).WithWrap("outer")
+
+	require.Len(t, err.Layers, 3)
+	assert.Equal(t, "", err.Layers[0].Message)
+	assert.Equal(t, map[string]interface{}{"foo": "foo"}, err.Layers[0].Fields)
+	assert.Equal(t, "wrap", err.Layers[1].Message)
+	assert.Equal(t, map[string]interface{}{"bar": "bar", "foo": "foo2"}, err.Layers[1].Fields)
+	assert.Equal(t, "outer", err.Layers[2].Message)
+	assert.Nil(t, err.Layers[2].Fields)
+	assert.Equal(t, map[string]interface{}{"bar": "bar", "foo": "foo2"}, err.Fields)
+
+	for i, want := range []string{
+		"errorw.testLayerOrigin .*errorw/layer_test.go:14",
+		"errorw.testLayerWrap .*errorw/layer_test.go:18",
+		"errorw.TestError_Layers .*errorw/layer_test.go:22",
+	} {
+		function, file, line := location(err.Layers[i].Frame)
+		assert.Regexp(t, regexp.MustCompile(want), fmt.Sprintf("%s %s:%d", function, file, line))
+	}
+}
+
+func TestError_LayersOfWrappedError(t *testing.T) {
+	err := Wrapf(errors.New("origin"), "wrap %d", 1)
+
+	// The origin is the caller of Wrapf
+	require.Len(t, err.Layers, 2)
+	for _, l := range err.Layers {
+		function, _, _ := location(l.Frame)
+		assert.Equal(t, "github.com/XSAM/go-hybrid/errorw.TestError_LayersOfWrappedError", function)
+	}
+	testFormatRegexp(t, 0, err.StackTrace()[0], "%+v",
+		"github.com/XSAM/go-hybrid/errorw.TestError_LayersOfWrappedError\n\t.*go-hybrid/errorw/layer_test.go:44")
+}
+
+func TestError_LayersOfLiteral(t *testing.T) {
+	err := &Error{
+		Err:     errors.New("origin"),
+		Wrapper: []string{"foo"},
+		Fields:  map[string]interface{}{"foo": "foo"},
+	}
+
+	result := err.WithField("bar", "bar").WithWrap("bar")
+	require.Len(t, result.Layers, 3)
+	assert.Equal(t, map[string]interface{}{"foo": "foo"}, result.Layers[0].Fields)
+	assert.Equal(t, map[string]interface{}{"bar": "bar"}, result.Layers[1].Fields)
+	assert.Equal(t, "bar", result.Layers[2].Message)
+	// Modified in place
+	assert.Same(t, err, result)
+}
+
+func TestError_LayersImmutable(t *testing.T) {
+	sentinel := NewMessage("origin").WithField("foo", "foo").Immutable()
+	err := sentinel.WithField("bar", "bar")
+
+	assert.Equal(t, map[string]interface{}{"foo": "foo"}, sentinel.Layers[0].Fields)
+	assert.Equal(t, map[string]interface{}{"foo": "foo", "bar": "bar"}, err.Layers[0].Fields)
+}
+
+func TestLayer_Format(t *testing.T) {
+	err := NewMessage("origin").WithWrap("wrap")
+	l := err.Layers[1]
+
+	assert.Equal(t, "wrap", fmt.Sprintf("%s", l))
+	assert.Equal(t, "wrap", fmt.Sprintf("%v", l))
+	assert.Regexp(t, regexp.MustCompile(
+		"^wrap\n\tgithub.com/XSAM/go-hybrid/errorw.TestLayer_Format\n\t\t.*go-hybrid/errorw/layer_test.go:81$"),
+		fmt.Sprintf("%+v", l))
+	assert.Regexp(t, regexp.MustCompile(
+		`^errorw.Layer\{Message:"wrap", Frame:"github.com/XSAM/go-hybrid/errorw.TestLayer_Format .*go-hybrid/errorw/layer_test.go:81", Fields:map\[string\]interface \{\}\(nil\)\}$`),
+		fmt.Sprintf("%#v", l))
+}
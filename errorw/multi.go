@@ -0,0 +1,248 @@
+package errorw
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Multi is an error which aggregates several errors, e.g. errors of batch jobs or validation.
+type Multi struct {
+	Errors []*Error
+}
+
+// Verify interface compliance at compile time
+var _ error = (*Multi)(nil)
+var _ zapcore.ArrayMarshaler = (*Multi)(nil)
+var _ interface{ GRPCStatus() *status.Status } = (*Multi)(nil)
+var _ fmt.Formatter = (*Multi)(nil)
+
+// MultiRender render the message of Multi.
+var MultiRender func(m *Multi) string
+
+// PlainMultiRender render each error by Render. e.g. "2 errors occurred: foo; bar"
+func PlainMultiRender(m *Multi) string {
+	var buf bytes.Buffer
+	buf.WriteString(m.summary() + ": ")
+	for i, e := range m.Errors {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(Render(e))
+	}
+	return buf.String()
+}
+
+func init() {
+	MultiRender = PlainMultiRender
+}
+
+// summary return the number of errors. e.g. "2 errors occurred"
+func (m *Multi) summary() string {
+	if len(m.Errors) == 1 {
+		return "1 error occurred"
+	}
+	return fmt.Sprintf("%d errors occurred", len(m.Errors))
+}
+
+// Join return an error which aggregates errs, or nil if all of errs are nil.
+// Nil errors are ignored, errors of Multi are flattened,
+// and errors which are not errorw.Error are created with the stack of caller.
+//
+//	err := errorw.Join(validateName(), validateAge())
+func Join(errs ...error) error {
+	m := Multi{Errors: flatten(nil, errs)}
+	return m.ErrorOrNil()
+}
+
+// Append return a new Multi with errs appended, errs are collected like Join.
+// m can be nil, and it is kept unchanged.
+//
+//	var m *errorw.Multi
+//	for _, job := range jobs {
+//		m = m.Append(job.Run())
+//	}
+//	return m.ErrorOrNil()
+func (m *Multi) Append(errs ...error) *Multi {
+	var list []*Error
+	if m != nil {
+		list = m.Errors[:len(m.Errors):len(m.Errors)]
+	}
+	return &Multi{Errors: flatten(list, errs)}
+}
+
+// flatten append errs to list, errors of Multi are flattened.
+// It must be called by Join or Append, so the stack starts from their caller.
+func flatten(list []*Error, errs []error) []*Error {
+	for _, err := range errs {
+		switch e := err.(type) {
+		case nil:
+		case *Multi:
+			if e != nil {
+				list = append(list, e.Errors...)
+			}
+		case *Error:
+			if e != nil {
+				list = append(list, e)
+			}
+		default:
+			list = append(list, newError(err, 5))
+		}
+	}
+	return list
+}
+
+// ErrorOrNil return m as an error, or nil if m has no error.
+func (m *Multi) ErrorOrNil() error {
+	if m == nil || len(m.Errors) == 0 {
+		return nil
+	}
+	return m
+}
+
+func (m *Multi) Error() string {
+	return MultiRender(m)
+}
+
+// Is report whether any of errors matches target.
+// Implement `errors.Is` interface.
+func (m *Multi) Is(target error) bool {
+	for _, e := range m.Errors {
+		if Is(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As find the first of errors which matches target, and set target to it.
+// Implement `errors.As` interface.
+func (m *Multi) As(target interface{}) bool {
+	for _, e := range m.Errors {
+		if As(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// codeSeverity is the severity of gRPC codes, from the most severe.
+// Server errors are more severe than client errors.
+var codeSeverity = []codes.Code{
+	codes.DataLoss,
+	codes.Internal,
+	codes.Unknown,
+	codes.Unavailable,
+	codes.DeadlineExceeded,
+	codes.ResourceExhausted,
+	codes.Unimplemented,
+	codes.Aborted,
+	codes.FailedPrecondition,
+	codes.PermissionDenied,
+	codes.Unauthenticated,
+	codes.OutOfRange,
+	codes.AlreadyExists,
+	codes.NotFound,
+	codes.InvalidArgument,
+	codes.Canceled,
+}
+
+func severity(code codes.Code) int {
+	for i, c := range codeSeverity {
+		if c == code {
+			return len(codeSeverity) - i
+		}
+	}
+	return 0
+}
+
+// GRPCStatus return a gRPC status with the most severe code of errors,
+// messages of errors which have that code, and the status of each error as details.
+// Details are `google.rpc.Status` messages, which keep codes, messages and details of errors.
+// Implement gRPC status.GRPCStatus function.
+func (m *Multi) GRPCStatus() *status.Status {
+	var result *status.Status
+	var messages []string
+	var members []*status.Status
+	for _, e := range m.Errors {
+		st := e.GRPCStatus()
+		if st == nil || st.Code() == codes.OK {
+			continue
+		}
+		members = append(members, st)
+
+		switch {
+		case result == nil || severity(st.Code()) > severity(result.Code()):
+			result, messages = st, []string{st.Message()}
+		case st.Code() == result.Code():
+			messages = append(messages, st.Message())
+		}
+	}
+	if result == nil {
+		return nil
+	}
+
+	p := result.Proto()
+	p.Message = strings.Join(messages, "; ")
+	p.Details = nil
+	result = status.FromProto(p)
+	for _, st := range members {
+		// Status of errors can always be marshaled, and the code of result isn't OK
+		if withDetails, err := result.WithDetails(st.Proto()); err == nil {
+			result = withDetails
+		}
+	}
+	return result
+}
+
+// MarshalLogArray is an implementation of `zapcore.ArrayMarshaler` interface,
+// each error is an object of errorw.Error.
+func (m *Multi) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, e := range m.Errors {
+		err := enc.AppendObject(e)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Format implement `fmt.Formatter` interface.
+// %+v and %#v print each error with the same verb, others are the same as errorw.Error.
+func (m *Multi) Format(s fmt.State, verb rune) {
+	if m == nil {
+		io.WriteString(s, "<nil>")
+		return
+	}
+
+	switch verb {
+	case 'v':
+		switch {
+		case s.Flag('+'):
+			io.WriteString(s, m.summary()+":")
+			for i, e := range m.Errors {
+				fmt.Fprintf(s, "\n[%d] %s", i, strings.ReplaceAll(fmt.Sprintf("%+v", e), "\n", "\n\t"))
+			}
+		case s.Flag('#'):
+			io.WriteString(s, "&errorw.Multi{Errors:[]*errorw.Error{")
+			for i, e := range m.Errors {
+				if i > 0 {
+					io.WriteString(s, ", ")
+				}
+				fmt.Fprintf(s, "%#v", e)
+			}
+			io.WriteString(s, "}}")
+		default:
+			io.WriteString(s, m.Error())
+		}
+	case 's':
+		io.WriteString(s, m.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", m.Error())
+	}
+}
@@ -0,0 +1,185 @@
+package errorw
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestJoin(t *testing.T) {
+	assert.Nil(t, Join())
+	assert.Nil(t, Join(nil, (*Error)(nil), (*Multi)(nil)))
+
+	foo, bar, baz := errors.New("foo"), NewMessage("bar"), errors.New("baz")
+	err := Join(foo, nil, Join(bar, baz))
+	require.IsType(t, &Multi{}, err)
+
+	m := err.(*Multi)
+	require.Len(t, m.Errors, 3)
+	assert.Equal(t, foo, m.Errors[0].Err)
+	assert.Equal(t, bar, m.Errors[1])
+	assert.Equal(t, baz, m.Errors[2].Err)
+	// Stack starts from the caller
+	testFormatRegexp(t, 0, m.Errors[0].StackTrace()[0], "%+v",
+		"github.com/XSAM/go-hybrid/errorw.TestJoin\n\t.*go-hybrid/errorw/multi_test.go:26")
+
+	assert.Equal(t, "3 errors occurred: foo; bar; baz", err.Error())
+	assert.Equal(t, "1 error occurred: foo", Join(foo).Error())
+}
+
+func TestMulti_Append(t *testing.T) {
+	var m *Multi
+	assert.Nil(t, m.ErrorOrNil())
+	assert.Nil(t, m.Append(nil).ErrorOrNil())
+
+	m = m.Append(errors.New("foo"))
+	m2 := m.Append(errors.New("bar"), Join(errors.New("baz")))
+	m3 := m.Append(errors.New("qux"))
+
+	assert.Len(t, m.Errors, 1)
+	assert.Equal(t, "3 errors occurred: foo; bar; baz", m2.Error())
+	assert.Equal(t, "2 errors occurred: foo; qux", m3.Error())
+	testFormatRegexp(t, 0, m2.Errors[1].StackTrace()[0], "%+v",
+		"github.com/XSAM/go-hybrid/errorw.TestMulti_Append\n\t.*go-hybrid/errorw/multi_test.go:48")
+}
+
+func TestMultiRender(t *testing.T) {
+	MultiRender = func(m *Multi) string {
+		return fmt.Sprintf("%d errors", len(m.Errors))
+	}
+	defer func() {
+		MultiRender = PlainMultiRender
+	}()
+
+	assert.Equal(t, "2 errors", Join(errors.New("foo"), errors.New("bar")).Error())
+}
+
+func TestMulti_IsAs(t *testing.T) {
+	root := &testAsError{}
+	err := fmt.Errorf("wrap: %w", Join(
+		errors.New("foo"),
+		Wrap(pkgerrors.Wrap(sql.ErrNoRows, "query"), "find"),
+		NewAPIError(status.New(codes.NotFound, "not found")),
+		root,
+	))
+
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.True(t, errors.Is(err, status.Error(codes.NotFound, "")))
+	assert.False(t, errors.Is(err, sql.ErrTxDone))
+	assert.True(t, Is(err, codes.NotFound))
+	assert.False(t, Is(err, codes.PermissionDenied))
+
+	var target *testAsError
+	assert.True(t, errors.As(err, &target))
+	assert.Equal(t, root, target)
+}
+
+func TestMulti_GRPCStatus(t *testing.T) {
+	invalid, err := status.New(codes.InvalidArgument, "invalid name").
+		WithDetails(status.New(codes.InvalidArgument, "name is required").Proto())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name            string
+		err             *Multi
+		expectedCode    codes.Code
+		expectedMessage string
+		expectedDetails []codes.Code
+	}{
+		{
+			name: "most severe code",
+			err: (&Multi{}).Append(
+				NewAPIError(invalid),
+				NewAPIError(status.New(codes.Unavailable, "db unavailable")),
+				NewAPIError(status.New(codes.NotFound, "not found")),
+				NewAPIError(status.New(codes.Unavailable, "cache unavailable")),
+			),
+			expectedCode:    codes.Unavailable,
+			expectedMessage: "db unavailable; cache unavailable",
+			expectedDetails: []codes.Code{codes.InvalidArgument, codes.Unavailable, codes.NotFound, codes.Unavailable},
+		},
+		{
+			name:            "internal error",
+			err:             (&Multi{}).Append(NewAPIError(invalid), errors.New("foo")),
+			expectedCode:    codes.Internal,
+			expectedMessage: "foo",
+			expectedDetails: []codes.Code{codes.InvalidArgument, codes.Internal},
+		},
+		{
+			name:            "client error",
+			err:             (&Multi{}).Append(NewAPIError(invalid), NewAPIError(status.New(codes.NotFound, "not found"))),
+			expectedCode:    codes.NotFound,
+			expectedMessage: "not found",
+			expectedDetails: []codes.Code{codes.InvalidArgument, codes.NotFound},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := tc.err.GRPCStatus()
+			assert.Equal(t, tc.expectedCode, st.Code())
+			assert.Equal(t, tc.expectedMessage, st.Message())
+
+			details := st.Details()
+			require.Len(t, details, len(tc.expectedDetails))
+			for i, detail := range details {
+				require.IsType(t, &spb.Status{}, detail)
+				assert.Equal(t, tc.expectedDetails[i], status.FromProto(detail.(*spb.Status)).Code())
+			}
+			// Details of errors are kept in their status
+			first := status.FromProto(details[0].(*spb.Status))
+			assert.Equal(t, "invalid name", first.Message())
+			assert.Len(t, first.Details(), 1)
+		})
+	}
+
+	// Join of API errors
+	st := Join(NewAPIError(status.New(codes.NotFound, "not found")), NewAPIError(invalid)).(*Multi).GRPCStatus()
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Len(t, st.Details(), 2)
+
+	// No status
+	assert.Nil(t, (&Multi{}).GRPCStatus())
+	assert.Nil(t, (&Multi{Errors: []*Error{{}}}).GRPCStatus())
+}
+
+func TestMulti_MarshalLogArray(t *testing.T) {
+	ob, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(ob)
+
+	err := Join(errors.New("foo"), NewMessage("bar").WithField("key", "value"))
+	logger.Info("test", zap.Array("errors", err.(*Multi)))
+
+	errs := logs.All()[0].ContextMap()["errors"].([]interface{})
+	require.Len(t, errs, 2)
+	assert.Equal(t, "foo", errs[0].(map[string]interface{})["msg"])
+	assert.Equal(t, "bar", errs[1].(map[string]interface{})["msg"])
+	assert.Equal(t, map[string]interface{}{"key": "value"}, errs[1].(map[string]interface{})["fields"])
+}
+
+func TestMulti_Format(t *testing.T) {
+	err := Join(errors.New("foo"), NewMessage("bar"))
+
+	assert.Equal(t, "2 errors occurred: foo; bar", fmt.Sprintf("%s", err))
+	assert.Equal(t, "2 errors occurred: foo; bar", fmt.Sprintf("%v", err))
+	assert.Equal(t, `"2 errors occurred: foo; bar"`, fmt.Sprintf("%q", err))
+	assert.Regexp(t, regexp.MustCompile(
+		"^2 errors occurred:\n\\[0\\] foo\n\twrappers:\n\t\tfoo\n(.|\n)*\n\\[1\\] bar\n\twrappers:\n"),
+		fmt.Sprintf("%+v", err))
+	assert.Regexp(t, regexp.MustCompile(
+		`^&errorw.Multi\{Errors:\[\]\*errorw.Error\{&errorw.Error\{Err:&errors.errorString\{s:"foo"\}, .*\}, &errorw.Error\{Err:&errors.errorString\{s:"bar"\}, .*\}\}\}$`),
+		fmt.Sprintf("%#v", err))
+	assert.Equal(t, "<nil>", fmt.Sprintf("%v", (*Multi)(nil)))
+}
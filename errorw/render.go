@@ -3,6 +3,8 @@ package errorw
 import (
 	"bytes"
 	"fmt"
+	"path/filepath"
+	"sort"
 )
 
 var Render func(e *Error) string
@@ -24,6 +26,51 @@ func PlainRender(e *Error) string {
 	return buf.String()
 }
 
+// FrameRender render error like PlainRender, with the caller and fields of each layer.
+// Set Render to use it: errorw.Render = errorw.FrameRender
+//
+//	find user (user.go:20 id:42): query (db.go:10): sql: no rows in result set (db.go:8 table:users)
+func FrameRender(e *Error) string {
+	var buf bytes.Buffer
+
+	layers := e.layers()
+	for i := len(layers) - 1; i >= 0; i-- {
+		l := layers[i]
+		if i == 0 {
+			if e.Err != nil {
+				buf.WriteString(e.Err.Error())
+			} else {
+				buf.WriteString("nil")
+			}
+		} else {
+			buf.WriteString(l.Message)
+		}
+
+		_, file, line := location(l.Frame)
+		buf.WriteString(fmt.Sprintf(" (%s:%d%s)", filepath.Base(file), line, sortedFields(l.Fields)))
+
+		if i > 0 {
+			buf.WriteString(": ")
+		}
+	}
+	return buf.String()
+}
+
+// sortedFields return fields sorted by keys, each field is prefixed with a space. e.g. " bar:1 foo:2"
+func sortedFields(fields map[string]interface{}) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		buf.WriteString(fmt.Sprintf(" %s:%+v", k, fields[k]))
+	}
+	return buf.String()
+}
+
 func init() {
 	Render = PlainRender
 }
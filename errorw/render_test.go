@@ -2,7 +2,10 @@ package errorw
 
 import (
 	"errors"
+	"regexp"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestPlainRender(t *testing.T) {
@@ -28,3 +31,19 @@ func TestPlainRender(t *testing.T) {
 		t.Fatal("unexpected result")
 	}
 }
+
+func TestFrameRender(t *testing.T) {
+	err := New(errors.New("foo")).
+		WithField("foo", "bar").
+		WithField("a", 1).
+		WithWrap("test").
+		WithField("baz", "qux").
+		WithWrap("test2")
+
+	assert.Regexp(t, regexp.MustCompile(
+		`^test2 \(render_test.go:\d+\): test \(render_test.go:\d+ baz:qux\): foo \(render_test.go:\d+ a:1 foo:bar\)$`),
+		FrameRender(err))
+
+	// Literal error
+	assert.Equal(t, "foo (unknown:0): nil (unknown:0)", FrameRender(&Error{Wrapper: []string{"foo"}}))
+}
@@ -30,5 +30,7 @@ func (e *Error) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 		field := zap.Any("fields", e.Fields)
 		field.AddTo(enc)
 	}
-	return nil
+
+	// Callers and fields of each layer
+	return enc.AddArray("layers", layers(e.layers()))
 }
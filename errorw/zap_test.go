@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
@@ -26,6 +27,22 @@ func TestError_MarshalLogObject(t *testing.T) {
 		"struct": testStruct{Value: "value"},
 	}, err["fields"])
 	assert.Contains(t, err["stack"], "go-hybrid/errorw/stack_test.go:11")
+
+	// Layers
+	layers := err["layers"].([]interface{})
+	require.Len(t, layers, 2)
+	origin := layers[0].(map[string]interface{})
+	assert.Equal(t, "github.com/XSAM/go-hybrid/errorw.init", origin["func"])
+	assert.Contains(t, origin["caller"], "go-hybrid/errorw/stack_test.go:11")
+	assert.Equal(t, map[string]interface{}{
+		"foo":    "bar",
+		"struct": testStruct{Value: "value"},
+	}, origin["fields"])
+	assert.NotContains(t, origin, "msg")
+	wrap := layers[1].(map[string]interface{})
+	assert.Equal(t, "wrap", wrap["msg"])
+	assert.Contains(t, wrap["caller"], "go-hybrid/errorw/stack_test.go:14")
+	assert.NotContains(t, wrap, "fields")
 }
 
 func TestError_MarshalLogObject2(t *testing.T) {
@@ -26,6 +26,8 @@ func Error(err error) zap.Field {
 			switch e := err.(type) {
 			case *errorw.Error:
 				return zap.Field{Key: "error", Type: zapcore.ObjectMarshalerType, Interface: e}
+			case *errorw.Multi:
+				return zap.Field{Key: "error", Type: zapcore.ArrayMarshalerType, Interface: e}
 			}
 		case environment.ModeProduction, environment.ModeStaging:
 			return zap.Field{Key: "error", Type: zapcore.StringType, String: err.Error()}
@@ -35,6 +37,8 @@ func Error(err error) zap.Field {
 		switch e := err.(type) {
 		case *errorw.Error:
 			return zap.Field{Key: "error", Type: zapcore.ObjectMarshalerType, Interface: e}
+		case *errorw.Multi:
+			return zap.Field{Key: "error", Type: zapcore.ArrayMarshalerType, Interface: e}
 		}
 	}
 	return zap.Error(err)
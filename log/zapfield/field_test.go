@@ -31,6 +31,7 @@ func TestError(t *testing.T) {
 
 	normalError := errors.New("error")
 	errorwError := errorw.New(normalError)
+	multiError := errorw.Join(normalError, errorwError)
 
 	testCases := []struct {
 		mode        environment.ModeType
@@ -80,6 +81,23 @@ func TestError(t *testing.T) {
 			interaction: environment.LogStyleText,
 			err:         errorwError,
 		},
+
+		// errorw multi error
+		{
+			mode:        environment.ModeDevelopment,
+			interaction: environment.LogStyleJSON,
+			err:         multiError,
+		},
+		{
+			mode:        environment.ModeDevelopment,
+			interaction: environment.LogStyleText,
+			err:         multiError,
+		},
+		{
+			mode:        environment.ModeProduction,
+			interaction: environment.LogStyleText,
+			err:         multiError,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -111,3 +129,15 @@ func newObservedLogger() (*log.Core, *observer.ObservedLogs) {
 	logger := log.Core{Logger: zap.New(ob)}
 	return &logger, logs
 }
+
+func TestMultiError(t *testing.T) {
+	logger, logs := newObservedLogger()
+	environment.LogStyle = environment.LogStyleJSON
+	logger.Info("testing", Error(errorw.Join(errors.New("foo"), errorw.NewMessage("bar"))))
+
+	l := logs.All()[0]
+	errs := l.ContextMap()["error"].([]interface{})
+	assert.Len(t, errs, 2)
+	assert.Equal(t, "foo", errs[0].(map[string]interface{})["msg"])
+	assert.Equal(t, "bar", errs[1].(map[string]interface{})["msg"])
+}
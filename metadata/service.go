@@ -1,5 +1,7 @@
 package metadata
 
+import "runtime/debug"
+
 // AppInfo return program's info
 func AppInfo() Info {
 	return appInfo
@@ -15,6 +17,27 @@ func AppName() string {
 	return appInfo.AppName
 }
 
+// Dependencies return modules which the program is built with, nil if build info is not available.
+func Dependencies() []Module {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	modules := make([]Module, 0, len(info.Deps))
+	for _, dep := range info.Deps {
+		m := Module{Path: dep.Path, Version: dep.Version}
+		if r := dep.Replace; r != nil {
+			m.Replace = r.Path
+			if r.Version != "" {
+				m.Replace += "@" + r.Version
+			}
+		}
+		modules = append(modules, m)
+	}
+	return modules
+}
+
 // RuntimeID return program's runtime id (uuid)
 func RuntimeID() string {
 	return appInfo.RuntimeID
@@ -28,3 +28,17 @@ func TestAppInfo(t *testing.T) {
 	// Runtime ID not empty
 	assert.NotEqual(t, "", info.RuntimeID)
 }
+
+func TestDependencies(t *testing.T) {
+	modules := Dependencies()
+
+	// Test binary is built with testify
+	var found bool
+	for _, m := range modules {
+		if m.Path == "github.com/stretchr/testify" {
+			found = true
+			assert.NotEqual(t, "", m.Version)
+		}
+	}
+	assert.True(t, found)
+}
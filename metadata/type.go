@@ -1,18 +1,28 @@
 package metadata
 
+// Info is the information of the application.
+// Keys of json and yaml are the field names, the same as the keys of Info in logs.
 type Info struct {
-	Version   Version
-	AppName   string
-	RuntimeID string
+	Version   Version `json:"Version" yaml:"Version"`
+	AppName   string  `json:"AppName" yaml:"AppName"`
+	RuntimeID string  `json:"RuntimeID" yaml:"RuntimeID"`
 }
 
 type Version struct {
-	GitVersion   string
-	GitCommit    string
-	GitBranch    string
-	GitTreeState string
-	BuildTime    string
-	GoVersion    string
-	Compiler     string
-	Platform     string
+	GitVersion   string `json:"GitVersion" yaml:"GitVersion"`
+	GitCommit    string `json:"GitCommit" yaml:"GitCommit"`
+	GitBranch    string `json:"GitBranch" yaml:"GitBranch"`
+	GitTreeState string `json:"GitTreeState" yaml:"GitTreeState"`
+	BuildTime    string `json:"BuildTime" yaml:"BuildTime"`
+	GoVersion    string `json:"GoVersion" yaml:"GoVersion"`
+	Compiler     string `json:"Compiler" yaml:"Compiler"`
+	Platform     string `json:"Platform" yaml:"Platform"`
+}
+
+// Module is a Go module which the program is built with.
+type Module struct {
+	Path    string `json:"Path" yaml:"Path"`
+	Version string `json:"Version" yaml:"Version"`
+	// Replacement of module, e.g. ../foo or github.com/foo/bar@v1.0.0
+	Replace string `json:"Replace,omitempty" yaml:"Replace,omitempty"`
 }
@@ -3,6 +3,7 @@ package metadata
 import (
 	"fmt"
 	"runtime"
+	"runtime/debug"
 
 	"github.com/google/uuid"
 )
@@ -23,4 +24,9 @@ func init() {
 			Platform:     fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
 		},
 	}
+
+	// Use the version of main module if it isn't injected, e.g. built by go install
+	if info, ok := debug.ReadBuildInfo(); ok && appInfo.Version.GitVersion == "" && info.Main.Version != "(devel)" {
+		appInfo.Version.GitVersion = info.Main.Version
+	}
 }